@@ -0,0 +1,195 @@
+package cookies
+
+import (
+	"cmp"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Jar is an http.CookieJar following the storage model of RFC 6265. Unlike
+// net/http/cookiejar it can list all of its cookies, so that they can be saved.
+type Jar struct {
+	mu      sync.Mutex
+	entries map[string]*entry // by domain, path and name
+	seq     int
+}
+
+type entry struct {
+	Name     string
+	Value    string
+	Domain   string // lower case host, without a leading dot
+	HostOnly bool   // sent to Domain only, not to its subdomains
+	Path     string
+	Secure   bool
+	HttpOnly bool
+	Expires  time.Time // zero for a session cookie
+	seq      int       // creation order
+}
+
+func (e *entry) key() string {
+	return e.Domain + ";" + e.Path + ";" + e.Name
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.Expires.IsZero() && !e.Expires.After(now)
+}
+
+func NewJar() *Jar {
+	return &Jar{entries: map[string]*entry{}}
+}
+
+func (j *Jar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	host := canonicalHost(u.Hostname())
+	if host == "" {
+		return
+	}
+	now := time.Now()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, cookie := range cookies {
+		e, ok := newEntry(cookie, host, u.EscapedPath(), now)
+		if !ok {
+			continue
+		}
+		if e.expired(now) {
+			delete(j.entries, e.key())
+			continue
+		}
+		j.add(e)
+	}
+}
+
+// add stores e, replacing a cookie with the same domain, path and name but
+// keeping its creation order.
+func (j *Jar) add(e *entry) {
+	if j.entries == nil {
+		j.entries = map[string]*entry{}
+	}
+	if old, ok := j.entries[e.key()]; ok {
+		e.seq = old.seq
+	} else {
+		j.seq++
+		e.seq = j.seq
+	}
+	j.entries[e.key()] = e
+}
+
+// newEntry applies the rules of RFC 6265, section 5.3, to a cookie received
+// from host for requestPath.
+func newEntry(cookie *http.Cookie, host, requestPath string, now time.Time) (*entry, bool) {
+	if cookie.Name == "" {
+		return nil, false
+	}
+	e := &entry{
+		Name:     cookie.Name,
+		Value:    cookie.Value,
+		Domain:   host,
+		HostOnly: true,
+		Path:     cookie.Path,
+		Secure:   cookie.Secure,
+		HttpOnly: cookie.HttpOnly,
+	}
+
+	switch {
+	case cookie.MaxAge < 0:
+		e.Expires = time.Unix(1, 0)
+	case cookie.MaxAge > 0:
+		e.Expires = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+	case !cookie.Expires.IsZero():
+		e.Expires = cookie.Expires
+	}
+
+	if domain := canonicalHost(strings.TrimPrefix(cookie.Domain, ".")); domain != "" {
+		// A cookie for another site or for a public suffix is rejected; a
+		// public suffix that is the host itself gets a host-only cookie.
+		if domain != host && (net.ParseIP(host) != nil || !strings.HasSuffix(host, "."+domain)) {
+			return nil, false
+		}
+		if suffix, _ := publicsuffix.PublicSuffix(domain); suffix == domain {
+			if domain != host {
+				return nil, false
+			}
+		} else {
+			e.Domain = domain
+			e.HostOnly = false
+		}
+	}
+
+	if !strings.HasPrefix(e.Path, "/") {
+		e.Path = defaultPath(requestPath)
+	}
+	return e, true
+}
+
+// defaultPath is the directory of the request path.
+func defaultPath(requestPath string) string {
+	i := strings.LastIndex(requestPath, "/")
+	if i <= 0 {
+		return "/"
+	}
+	return requestPath[:i]
+}
+
+func (j *Jar) Cookies(u *url.URL) []*http.Cookie {
+	host := canonicalHost(u.Hostname())
+	requestPath := u.EscapedPath()
+	if requestPath == "" {
+		requestPath = "/"
+	}
+	secure := u.Scheme == "https"
+	now := time.Now()
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var matched []*entry
+	for key, e := range j.entries {
+		if e.expired(now) {
+			delete(j.entries, key)
+			continue
+		}
+		if (e.Secure && !secure) || !e.domainMatch(host) || !pathMatch(requestPath, e.Path) {
+			continue
+		}
+		matched = append(matched, e)
+	}
+
+	// Longer paths first, then older cookies first, as RFC 6265 recommends.
+	slices.SortFunc(matched, func(a, b *entry) int {
+		return cmp.Or(cmp.Compare(len(b.Path), len(a.Path)), cmp.Compare(a.seq, b.seq))
+	})
+	cookies := make([]*http.Cookie, len(matched))
+	for i, e := range matched {
+		cookies[i] = &http.Cookie{Name: e.Name, Value: e.Value}
+	}
+	return cookies
+}
+
+func (e *entry) domainMatch(host string) bool {
+	if host == e.Domain {
+		return true
+	}
+	return !e.HostOnly && strings.HasSuffix(host, "."+e.Domain) && net.ParseIP(host) == nil
+}
+
+// pathMatch reports whether cookiePath is requestPath or one of its
+// directories.
+func pathMatch(requestPath, cookiePath string) bool {
+	if !strings.HasPrefix(requestPath, cookiePath) {
+		return false
+	}
+	return len(requestPath) == len(cookiePath) ||
+		strings.HasSuffix(cookiePath, "/") ||
+		requestPath[len(cookiePath)] == '/'
+}
+
+func canonicalHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
@@ -0,0 +1,103 @@
+package cookies
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// httpOnlyPrefix marks HttpOnly cookies in cookies.txt, in the way of curl
+// and browser exports. Other lines starting with "#" are comments.
+const httpOnlyPrefix = "#HttpOnly_"
+
+// Load adds the cookies of a Netscape cookies.txt file. Expired cookies are
+// skipped; session cookies, with expiry 0, are kept.
+func (j *Jar) Load(r io.Reader) error {
+	now := time.Now()
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		httpOnly := strings.HasPrefix(text, httpOnlyPrefix)
+		text = strings.TrimPrefix(text, httpOnlyPrefix)
+		if strings.TrimSpace(text) == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Split(text, "\t")
+		if len(fields) != 7 {
+			return fmt.Errorf("cookies.txt line %d: expected 7 tab-separated fields, got %d", line, len(fields))
+		}
+		expires, err := strconv.ParseInt(fields[4], 10, 64)
+		if err != nil {
+			return fmt.Errorf("cookies.txt line %d: invalid expiry %q", line, fields[4])
+		}
+
+		e := &entry{
+			Name:     fields[5],
+			Value:    fields[6],
+			Domain:   canonicalHost(strings.TrimPrefix(fields[0], ".")),
+			HostOnly: !strings.EqualFold(fields[1], "TRUE"),
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		if expires > 0 {
+			e.Expires = time.Unix(expires, 0)
+		}
+		if e.Name == "" || e.Domain == "" || e.expired(now) {
+			continue
+		}
+
+		j.mu.Lock()
+		j.add(e)
+		j.mu.Unlock()
+	}
+	return scanner.Err()
+}
+
+// Save writes the cookies in the Netscape cookies.txt format. Session
+// cookies are written only with keepSession.
+func (j *Jar) Save(w io.Writer, keepSession bool) error {
+	now := time.Now()
+	j.mu.Lock()
+	var entries []*entry
+	for _, e := range j.entries {
+		if !e.expired(now) && (keepSession || !e.Expires.IsZero()) {
+			entries = append(entries, e)
+		}
+	}
+	j.mu.Unlock()
+	slices.SortFunc(entries, func(a, b *entry) int {
+		return strings.Compare(a.key(), b.key())
+	})
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "# Netscape HTTP Cookie File")
+	for _, e := range entries {
+		domain := e.Domain
+		if !e.HostOnly {
+			domain = "." + domain
+		}
+		if e.HttpOnly {
+			domain = httpOnlyPrefix + domain
+		}
+		var expires int64
+		if !e.Expires.IsZero() {
+			expires = e.Expires.Unix()
+		}
+		fmt.Fprintf(out, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n",
+			domain, netscapeBool(!e.HostOnly), e.Path, netscapeBool(e.Secure), expires, e.Name, e.Value)
+	}
+	return out.Flush()
+}
+
+func netscapeBool(value bool) string {
+	if value {
+		return "TRUE"
+	}
+	return "FALSE"
+}
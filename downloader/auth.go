@@ -0,0 +1,327 @@
+package downloader
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Credentials are a user name and password for Basic or Digest auth.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// authTransport adds credentials to requests for the hosts they belong to.
+// Every request, including each hop of a redirect, is matched on its own,
+// so credentials never leave their host.
+type authTransport struct {
+	base        http.RoundTripper
+	credentials map[string]Credentials // by host or host:port
+	bearer      map[string]string      // tokens by host or host:port
+
+	mu         sync.Mutex
+	challenges map[string]*challenge // last accepted challenge by host
+}
+
+func newAuthTransport(base http.RoundTripper, credentials map[string]Credentials, bearer map[string]string) *authTransport {
+	return &authTransport{
+		base:        base,
+		credentials: lowerKeys(credentials),
+		bearer:      lowerKeys(bearer),
+		challenges:  map[string]*challenge{},
+	}
+}
+
+func lowerKeys[V any](m map[string]V) map[string]V {
+	lowered := make(map[string]V, len(m))
+	for key, value := range m {
+		lowered[strings.ToLower(key)] = value
+	}
+	return lowered
+}
+
+// lookup finds the entry for u, preferring host:port over the bare host.
+func lookup[V any](m map[string]V, u *url.URL) (V, string, bool) {
+	for _, key := range []string{strings.ToLower(u.Host), strings.ToLower(u.Hostname())} {
+		if value, ok := m[key]; ok {
+			return value, key, true
+		}
+	}
+	var zero V
+	return zero, "", false
+}
+
+func (t *authTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Header.Get("Authorization") != "" {
+		return t.base.RoundTrip(request)
+	}
+	if token, _, ok := lookup(t.bearer, request.URL); ok {
+		return t.base.RoundTrip(withAuthorization(request, "Bearer "+token))
+	}
+	credentials, host, ok := lookup(t.credentials, request.URL)
+	if !ok {
+		return t.base.RoundTrip(request)
+	}
+
+	// Once a host has challenged, later requests answer it up front.
+	t.mu.Lock()
+	known := t.challenges[host]
+	t.mu.Unlock()
+	first := request
+	if known != nil {
+		first = withAuthorization(request, t.authorization(known, credentials, request))
+	}
+	response, err := t.base.RoundTrip(first)
+	if err != nil || response.StatusCode != http.StatusUnauthorized {
+		return response, err
+	}
+
+	c := chooseChallenge(response.Header.Values("WWW-Authenticate"))
+	if c == nil {
+		return response, nil
+	}
+	retry, err := replayable(request)
+	if err != nil || retry == nil {
+		return response, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(response.Body, 64<<10))
+	_ = response.Body.Close()
+
+	t.mu.Lock()
+	t.challenges[host] = c
+	t.mu.Unlock()
+	return t.base.RoundTrip(withAuthorization(retry, t.authorization(c, credentials, retry)))
+}
+
+// replayable returns a copy of request with a fresh body, or nil if the
+// body cannot be sent again.
+func replayable(request *http.Request) (*http.Request, error) {
+	if request.Body == nil || request.Body == http.NoBody {
+		return request, nil
+	}
+	if request.GetBody == nil {
+		return nil, nil
+	}
+	body, err := request.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retry := request.Clone(request.Context())
+	retry.Body = body
+	return retry, nil
+}
+
+func withAuthorization(request *http.Request, value string) *http.Request {
+	request = request.Clone(request.Context())
+	request.Header.Set("Authorization", value)
+	return request
+}
+
+func (t *authTransport) authorization(c *challenge, credentials Credentials, request *http.Request) string {
+	if c.scheme == "basic" {
+		return basicAuthorization(credentials)
+	}
+	t.mu.Lock()
+	c.count++
+	count := c.count
+	t.mu.Unlock()
+	return c.digest(credentials, request.Method, request.URL.RequestURI(), count)
+}
+
+func basicAuthorization(credentials Credentials) string {
+	request := &http.Request{Header: http.Header{}}
+	request.SetBasicAuth(credentials.Username, credentials.Password)
+	return request.Header.Get("Authorization")
+}
+
+// challenge is a parsed WWW-Authenticate challenge.
+type challenge struct {
+	scheme string // lower case
+	params map[string]string
+	count  int // nonce count of Digest, guarded by authTransport.mu
+}
+
+// chooseChallenge picks the strongest supported challenge: Digest with
+// SHA-256, Digest with MD5, then Basic.
+func chooseChallenge(headers []string) *challenge {
+	var best *challenge
+	bestRank := 0
+	for _, header := range headers {
+		for _, c := range parseChallenges(header) {
+			if rank := c.rank(); rank > bestRank {
+				best, bestRank = c, rank
+			}
+		}
+	}
+	return best
+}
+
+func (c *challenge) rank() int {
+	switch c.scheme {
+	case "basic":
+		return 1
+	case "digest":
+		if c.params["nonce"] == "" || !c.supportsQop() {
+			return 0
+		}
+		switch strings.ToUpper(c.params["algorithm"]) {
+		case "", "MD5", "MD5-SESS":
+			return 2
+		case "SHA-256", "SHA-256-SESS":
+			return 3
+		}
+	}
+	return 0
+}
+
+// supportsQop reports whether the challenge allows qop=auth, or is an
+// RFC 2069 challenge without qop.
+func (c *challenge) supportsQop() bool {
+	qop, ok := c.params["qop"]
+	if !ok {
+		return true
+	}
+	for _, option := range strings.Split(qop, ",") {
+		if strings.EqualFold(strings.TrimSpace(option), "auth") {
+			return true
+		}
+	}
+	return false
+}
+
+// digest computes a Digest Authorization header as in RFC 7616.
+func (c *challenge) digest(credentials Credentials, method, uri string, count int) string {
+	algorithm := strings.ToUpper(c.params["algorithm"])
+	newHash := md5.New
+	if strings.HasPrefix(algorithm, "SHA-256") {
+		newHash = sha256.New
+	}
+	h := func(parts ...string) string {
+		return hashHex(newHash(), strings.Join(parts, ":"))
+	}
+
+	realm, nonce := c.params["realm"], c.params["nonce"]
+	cnonce := newCnonce()
+	nc := fmt.Sprintf("%08x", count)
+	ha1 := h(credentials.Username, realm, credentials.Password)
+	if strings.HasSuffix(algorithm, "-SESS") {
+		ha1 = h(ha1, nonce, cnonce)
+	}
+	ha2 := h(method, uri)
+
+	fields := []string{
+		"username=" + quote(credentials.Username),
+		"realm=" + quote(realm),
+		"nonce=" + quote(nonce),
+		"uri=" + quote(uri),
+	}
+	if _, ok := c.params["qop"]; ok {
+		fields = append(fields,
+			"qop=auth",
+			"nc="+nc,
+			"cnonce="+quote(cnonce),
+			"response="+quote(h(ha1, nonce, nc, cnonce, "auth", ha2)))
+	} else {
+		fields = append(fields, "response="+quote(h(ha1, nonce, ha2)))
+	}
+	if algorithm != "" {
+		fields = append(fields, "algorithm="+c.params["algorithm"])
+	}
+	if opaque, ok := c.params["opaque"]; ok {
+		fields = append(fields, "opaque="+quote(opaque))
+	}
+	return "Digest " + strings.Join(fields, ", ")
+}
+
+func hashHex(h hash.Hash, data string) string {
+	h.Write([]byte(data))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func newCnonce() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func quote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// parseChallenges parses the challenges of a WWW-Authenticate header, which
+// may hold several of them: Digest realm="a", nonce="b", Basic realm="c".
+func parseChallenges(header string) []*challenge {
+	var challenges []*challenge
+	var current *challenge
+	s := header
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return challenges
+		}
+		token := s[:tokenEnd(s)]
+		if token == "" {
+			return challenges // malformed, keep what was parsed
+		}
+		s = strings.TrimLeft(s[len(token):], " \t")
+
+		if !strings.HasPrefix(s, "=") || current == nil {
+			// A token not followed by "=" starts a new challenge. A token68
+			// after the scheme is kept but not used.
+			current = &challenge{scheme: strings.ToLower(token), params: map[string]string{}}
+			challenges = append(challenges, current)
+			continue
+		}
+
+		s = strings.TrimLeft(s[1:], " \t")
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			value, s = unquote(s)
+		} else {
+			end := strings.IndexAny(s, ", \t")
+			if end < 0 {
+				end = len(s)
+			}
+			value, s = s[:end], s[end:]
+		}
+		current.params[strings.ToLower(token)] = value
+	}
+}
+
+func tokenEnd(s string) int {
+	for i, r := range s {
+		if r == '=' || r == ',' || r == ' ' || r == '\t' || r == '"' {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// unquote reads a quoted string from the start of s and returns it with the
+// rest of s.
+func unquote(s string) (string, string) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return b.String(), s[i+1:]
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), ""
+}
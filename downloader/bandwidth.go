@@ -0,0 +1,136 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenBucket limits throughput to rate bytes per second, allowing bursts of
+// an eighth of a second.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func NewTokenBucket(rate int64) *TokenBucket {
+	burst := max(float64(rate)/8, 512)
+	return &TokenBucket{rate: float64(rate), burst: burst, tokens: burst, last: time.Now()}
+}
+
+// Wait takes n tokens, waiting until the bucket has refilled enough for them.
+// Tokens taken ahead of time are owed by later callers, which keeps waiting
+// callers in order.
+func (b *TokenBucket) Wait(ctx context.Context, n int) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens -= float64(n)
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	return sleep(ctx, delay)
+}
+
+type BandwidthSettings struct {
+	LimitRate           int64 // bytes per second over all downloads, 0 for no limit
+	ConnectionLimitRate int64 // bytes per second for every download, 0 for no limit
+}
+
+// ThrottledDownloader limits the rate at which the bodies of another
+// Downloader are read.
+type ThrottledDownloader struct {
+	Downloader Downloader
+	Settings   BandwidthSettings
+	total      *TokenBucket
+}
+
+func NewThrottledDownloader(downloader Downloader, settings BandwidthSettings) *ThrottledDownloader {
+	d := &ThrottledDownloader{Downloader: downloader, Settings: settings}
+	if settings.LimitRate > 0 {
+		d.total = NewTokenBucket(settings.LimitRate)
+	}
+	return d
+}
+
+func (d *ThrottledDownloader) Download(ctx context.Context, url string) (*Response, error) {
+	response, err := d.Downloader.Download(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	var buckets []*TokenBucket
+	if d.total != nil {
+		buckets = append(buckets, d.total)
+	}
+	if d.Settings.ConnectionLimitRate > 0 {
+		buckets = append(buckets, NewTokenBucket(d.Settings.ConnectionLimitRate))
+	}
+	if len(buckets) > 0 {
+		response.Body = &throttledBody{ReadCloser: response.Body, ctx: ctx, buckets: buckets}
+	}
+	return response, nil
+}
+
+// throttledBody waits for tokens after every read. Reads are no larger than
+// the smallest burst so that a single read never exceeds the limit.
+type throttledBody struct {
+	io.ReadCloser
+	ctx     context.Context
+	buckets []*TokenBucket
+}
+
+func (b *throttledBody) Read(p []byte) (int, error) {
+	for _, bucket := range b.buckets {
+		if limit := int(bucket.burst); len(p) > limit {
+			p = p[:limit]
+		}
+	}
+	n, err := b.ReadCloser.Read(p)
+	for _, bucket := range b.buckets {
+		if waitErr := bucket.Wait(b.ctx, n); waitErr != nil && err == nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// ParseRate parses a rate in bytes per second with an optional binary suffix
+// k, M or G, e.g. "500k" or "2M".
+func ParseRate(value string) (int64, error) {
+	number := strings.TrimSpace(value)
+	multiplier := 1.0
+	if number != "" {
+		switch strings.ToLower(number[len(number)-1:]) {
+		case "k":
+			multiplier = 1 << 10
+		case "m":
+			multiplier = 1 << 20
+		case "g":
+			multiplier = 1 << 30
+		}
+		if multiplier != 1 {
+			number = number[:len(number)-1]
+		}
+	}
+
+	rate, err := strconv.ParseFloat(number, 64)
+	rate *= multiplier
+	// NaN and values out of the range of int64 have no defined conversion.
+	if err != nil || math.IsNaN(rate) || math.IsInf(rate, 0) || rate < 0 || rate >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid rate %q, expected e.g. 500k or 2M", value)
+	}
+	return int64(rate), nil
+}
@@ -0,0 +1,56 @@
+package downloader
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Conditions are the validators of a copy downloaded before. A server that
+// still has the same version answers with Response.NotModified set.
+type Conditions struct {
+	ETag         string // sent as If-None-Match
+	LastModified string // sent as If-Modified-Since
+}
+
+type conditionsKey struct{}
+
+// WithConditions makes downloads with ctx conditional on c.
+func WithConditions(ctx context.Context, c Conditions) context.Context {
+	return context.WithValue(ctx, conditionsKey{}, c)
+}
+
+func conditionsFrom(ctx context.Context) Conditions {
+	c, _ := ctx.Value(conditionsKey{}).(Conditions)
+	return c
+}
+
+// Range asks for the document from Start on, provided that it still matches
+// the validator IfRange. Otherwise the server sends all of it.
+type Range struct {
+	Start   int64
+	IfRange string
+}
+
+type rangeKey struct{}
+
+// WithRange makes downloads with ctx resume at r.Start. The Offset of the
+// response tells whether they did.
+func WithRange(ctx context.Context, r Range) context.Context {
+	return context.WithValue(ctx, rangeKey{}, r)
+}
+
+func rangeFrom(ctx context.Context) Range {
+	r, _ := ctx.Value(rangeKey{}).(Range)
+	return r
+}
+
+// RangeValidator returns the value for If-Range that identifies the version
+// described by header: a strong ETag or else Last-Modified. It is empty when
+// the version cannot be identified and a download must not be resumed.
+func RangeValidator(header http.Header) string {
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		return etag
+	}
+	return header.Get("Last-Modified")
+}
@@ -0,0 +1,109 @@
+package downloader
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+const DefaultUserAgent = "wget-go/1.0"
+
+type HTTPDownloaderSettings struct {
+	ConnectTimeout time.Duration
+	// ReadTimeout limits how long the response may stay idle, both while
+	// waiting for headers and between reads of the body.
+	ReadTimeout time.Duration
+	// Timeout limits the whole request including the body.
+	Timeout            time.Duration
+	UserAgent          string
+	Header             http.Header
+	ProxyURL           string
+	CACertFile         string // PEM bundle trusted in addition to the system roots
+	InsecureSkipVerify bool
+	// MaxRedirects limits followed redirects. Zero keeps the net/http
+	// default of 10, a negative value disables redirects.
+	MaxRedirects int
+	// Jar stores cookies set by responses and sends them with later
+	// requests, redirects included. Nil disables cookies.
+	Jar http.CookieJar
+	// Credentials and BearerTokens are keyed by host or host:port and are
+	// sent only to that host. Credentials answer Basic and Digest
+	// challenges; a bearer token is sent with every request.
+	Credentials  map[string]Credentials
+	BearerTokens map[string]string
+}
+
+func NewHTTPDownloader(settings HTTPDownloaderSettings) (*HTTPDownloader, error) {
+	client, err := newHTTPClient(settings)
+	if err != nil {
+		return nil, err
+	}
+	return &HTTPDownloader{Settings: settings, client: client}, nil
+}
+
+func newHTTPClient(settings HTTPDownloaderSettings) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   settings.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.ResponseHeaderTimeout = settings.ReadTimeout
+
+	if settings.ProxyURL != "" {
+		proxy, err := url.Parse(settings.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: settings.InsecureSkipVerify}
+	if settings.CACertFile != "" {
+		pool, err := loadCertPool(settings.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	var roundTripper http.RoundTripper = transport
+	if len(settings.Credentials) > 0 || len(settings.BearerTokens) > 0 {
+		roundTripper = newAuthTransport(transport, settings.Credentials, settings.BearerTokens)
+	}
+	client := &http.Client{
+		Transport: roundTripper,
+		Timeout:   settings.Timeout,
+		Jar:       settings.Jar,
+	}
+	if settings.MaxRedirects != 0 {
+		client.CheckRedirect = func(request *http.Request, via []*http.Request) error {
+			if len(via) > settings.MaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", max(settings.MaxRedirects, 0))
+			}
+			return nil
+		}
+	}
+	return client, nil
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New(file + ": no certificates found")
+	}
+	return pool, nil
+}
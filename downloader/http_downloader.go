@@ -1,43 +1,250 @@
 package downloader
 
 import (
+	"bufio"
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Downloader interface {
-	Download(ctx context.Context, url string) (data []byte, err error)
+	Download(ctx context.Context, url string) (*Response, error)
 }
 
-type HTTPDownloader struct{}
+// Response is a document being downloaded with the metadata needed to
+// process it. The caller reads the document from Body and must close it.
+type Response struct {
+	URL         string // final URL after redirects
+	ContentType string
+	Header      http.Header
+	Body        io.ReadCloser
+	// NotModified is set when the server confirmed the Conditions of the
+	// request; Body is then empty.
+	NotModified bool
+	// Offset is the position of Body in the document, non-zero when a
+	// download with a Range was resumed.
+	Offset int64
+}
+
+// ReadAll reads at most limit bytes of the body and closes it.
+func (r *Response) ReadAll(limit int64) ([]byte, error) {
+	defer r.Body.Close()
+	return io.ReadAll(io.LimitReader(r.Body, limit))
+}
+
+// HTTPDownloader downloads over HTTP(S). The zero value uses
+// http.DefaultClient; use NewHTTPDownloader to apply settings.
+type HTTPDownloader struct {
+	Settings HTTPDownloaderSettings
+	client   *http.Client
+}
+
+func (d *HTTPDownloader) Download(ctx context.Context, url string) (*Response, error) {
+	return d.do(ctx, "GET", url, nil)
+}
 
-func (d *HTTPDownloader) Download(ctx context.Context, url string) ([]byte, error) {
-	request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+// PostForm submits values the way a browser submits an HTML form and
+// returns the response after redirects.
+func (d *HTTPDownloader) PostForm(ctx context.Context, url string, values neturl.Values) (*Response, error) {
+	return d.do(ctx, "POST", url, values)
+}
+
+// do sends a GET request, or a POST request with form when it is not nil.
+func (d *HTTPDownloader) do(ctx context.Context, method, url string, form neturl.Values) (*Response, error) {
+	// The context outlives this call: it is released when the body is closed.
+	ctx, cancel := context.WithCancelCause(ctx)
+	var timer *time.Timer
+	if d.Settings.ReadTimeout > 0 {
+		timer = time.AfterFunc(d.Settings.ReadTimeout, func() { cancel(ErrReadTimeout) })
+	}
+	release := func() {
+		if timer != nil {
+			timer.Stop()
+		}
+		cancel(nil)
+	}
+
+	var requestBody io.Reader
+	if form != nil {
+		requestBody = strings.NewReader(form.Encode())
+	}
+	request, err := http.NewRequestWithContext(ctx, method, url, requestBody)
 	if err != nil {
+		release()
 		return nil, err
 	}
+	d.setHeaders(request)
+	if form != nil {
+		request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	setConditions(request, conditionsFrom(ctx))
+	requested := rangeFrom(ctx)
+	setRange(request, requested)
 
-	response, err := http.DefaultClient.Do(request)
+	response, err := d.httpClient().Do(request)
 	if err != nil {
+		err = readError(ctx, err)
+		release()
 		return nil, err
 	}
-	defer func(Body io.ReadCloser) {
-		_ = Body.Close()
-	}(response.Body)
 
-	if response.StatusCode < 200 || response.StatusCode >= 300 {
-		return nil, &DownloadError{URL: url, StatusCode: response.StatusCode}
+	notModified := response.StatusCode == http.StatusNotModified
+	if !notModified && (response.StatusCode < 200 || response.StatusCode >= 300) {
+		_ = response.Body.Close()
+		release()
+		return nil, &DownloadError{
+			URL:        url,
+			StatusCode: response.StatusCode,
+			RetryAfter: parseRetryAfter(response.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 
-	return io.ReadAll(response.Body)
+	var offset int64
+	if response.StatusCode == http.StatusPartialContent {
+		offset, err = parseContentRange(response.Header.Get("Content-Range"))
+		if err != nil || offset != requested.Start {
+			_ = response.Body.Close()
+			release()
+			return nil, fmt.Errorf("%s: unexpected Content-Range %q for a request from byte %d",
+				url, response.Header.Get("Content-Range"), requested.Start)
+		}
+	}
+
+	var reader io.Reader = response.Body
+	if timer != nil {
+		reader = &idleTimeoutReader{r: reader, timer: timer, timeout: d.Settings.ReadTimeout}
+	}
+	body := &responseBody{
+		Reader:  bufio.NewReaderSize(reader, sniffLen),
+		ctx:     ctx,
+		body:    response.Body,
+		release: release,
+	}
+
+	contentType := response.Header.Get("Content-Type")
+	if contentType == "" && !notModified {
+		// A read error is kept by bufio and returned by the next Read.
+		head, _ := body.Reader.Peek(sniffLen)
+		contentType = http.DetectContentType(head)
+	}
+	return &Response{
+		URL:         response.Request.URL.String(),
+		ContentType: contentType,
+		Header:      response.Header,
+		Body:        body,
+		NotModified: notModified,
+		Offset:      offset,
+	}, nil
+}
+
+// sniffLen is the amount of data http.DetectContentType looks at.
+const sniffLen = 512
+
+// responseBody reports read timeouts as ErrReadTimeout and releases the
+// request context on Close.
+type responseBody struct {
+	*bufio.Reader
+	ctx     context.Context
+	body    io.Closer
+	release func()
+}
+
+func (b *responseBody) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	if err != nil && err != io.EOF {
+		err = readError(b.ctx, err)
+	}
+	return n, err
+}
+
+func (b *responseBody) Close() error {
+	err := b.body.Close()
+	b.release()
+	return err
+}
+
+func (d *HTTPDownloader) httpClient() *http.Client {
+	if d.client == nil {
+		return http.DefaultClient
+	}
+	return d.client
+}
+
+func (d *HTTPDownloader) setHeaders(request *http.Request) {
+	for key, values := range d.Settings.Header {
+		for _, value := range values {
+			request.Header.Add(key, value)
+		}
+	}
+	userAgent := d.Settings.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	if request.Header.Get("User-Agent") == "" {
+		request.Header.Set("User-Agent", userAgent)
+	}
+}
+
+func setConditions(request *http.Request, c Conditions) {
+	if c.ETag != "" {
+		request.Header.Set("If-None-Match", c.ETag)
+	}
+	if c.LastModified != "" {
+		request.Header.Set("If-Modified-Since", c.LastModified)
+	}
+}
+
+func setRange(request *http.Request, r Range) {
+	if r.Start <= 0 {
+		return
+	}
+	request.Header.Set("Range", "bytes="+strconv.FormatInt(r.Start, 10)+"-")
+	if r.IfRange != "" {
+		request.Header.Set("If-Range", r.IfRange)
+	}
+}
+
+// parseContentRange returns the first byte position of a Content-Range such
+// as "bytes 100-199/200".
+func parseContentRange(value string) (int64, error) {
+	rest, ok := strings.CutPrefix(value, "bytes ")
+	if !ok {
+		return 0, errors.New("unsupported Content-Range unit")
+	}
+	start, _, ok := strings.Cut(rest, "-")
+	if !ok {
+		return 0, errors.New("malformed Content-Range")
+	}
+	return strconv.ParseInt(start, 10, 64)
 }
 
 type DownloadError struct {
 	URL        string
 	StatusCode int
+	RetryAfter time.Duration // from the Retry-After header, zero if absent
 }
 
 func (e *DownloadError) Error() string {
 	return e.URL + ": " + http.StatusText(e.StatusCode)
 }
+
+// parseRetryAfter accepts both forms of Retry-After: delay in seconds and an
+// HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,32 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var ErrReadTimeout = errors.New("read timeout")
+
+// idleTimeoutReader restarts the read timeout after every read, so that it
+// limits the time between reads instead of the whole transfer.
+type idleTimeoutReader struct {
+	r       io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func (r *idleTimeoutReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.timer.Reset(r.timeout)
+	return n, err
+}
+
+// readError reports ErrReadTimeout instead of the cancellation it caused.
+func readError(ctx context.Context, err error) error {
+	if errors.Is(context.Cause(ctx), ErrReadTimeout) {
+		return ErrReadTimeout
+	}
+	return err
+}
@@ -0,0 +1,69 @@
+package downloader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseNetrc reads the machine entries of a .netrc file as credentials by
+// host. The "default" entry is ignored: it would send the same password to
+// every host a crawl reaches. Macro definitions are skipped.
+func ParseNetrc(r io.Reader) (map[string]Credentials, error) {
+	credentials := map[string]Credentials{}
+	scanner := bufio.NewScanner(r)
+	var (
+		machine string // "" outside a machine entry
+		current Credentials
+		inMacro bool
+	)
+	flush := func() {
+		if machine != "" {
+			credentials[strings.ToLower(machine)] = current
+		}
+		machine, current = "", Credentials{}
+	}
+
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if inMacro {
+			// A macro ends at an empty line.
+			inMacro = strings.TrimSpace(text) != ""
+			continue
+		}
+		fields := strings.Fields(text)
+		for i := 0; i < len(fields); i++ {
+			switch keyword := fields[i]; keyword {
+			case "default":
+				flush()
+			case "macdef":
+				flush()
+				inMacro = true
+				i = len(fields)
+			case "machine", "login", "password", "account":
+				if i+1 >= len(fields) {
+					return nil, fmt.Errorf(".netrc line %d: %s without a value", line, keyword)
+				}
+				i++
+				switch keyword {
+				case "machine":
+					flush()
+					machine = fields[i]
+				case "login":
+					current.Username = fields[i]
+				case "password":
+					current.Password = fields[i]
+				}
+			default:
+				if strings.HasPrefix(keyword, "#") {
+					i = len(fields)
+					continue
+				}
+				return nil, fmt.Errorf(".netrc line %d: unexpected %q", line, keyword)
+			}
+		}
+	}
+	flush()
+	return credentials, scanner.Err()
+}
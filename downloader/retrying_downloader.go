@@ -0,0 +1,207 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type RetrySettings struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	// MaxDelay caps the backoff. A failure whose Retry-After asks for a
+	// longer delay is returned instead of being retried early.
+	MaxDelay time.Duration
+}
+
+// ErrChanged is returned when a broken download cannot be resumed because
+// the document changed in the meantime.
+var ErrChanged = errors.New("document changed during download")
+
+// RetryingDownloader retries transient failures of another Downloader with
+// jittered exponential backoff. A body that breaks off is resumed with a Range
+// request when its version can be identified; all attempts, including those
+// to resume, count towards MaxAttempts.
+type RetryingDownloader struct {
+	Downloader Downloader
+	Settings   RetrySettings
+}
+
+func NewRetryingDownloader(downloader Downloader, settings RetrySettings) *RetryingDownloader {
+	return &RetryingDownloader{Downloader: downloader, Settings: settings}
+}
+
+func (d *RetryingDownloader) Download(ctx context.Context, url string) (*Response, error) {
+	response, attempt, err := d.download(ctx, url, 1)
+	if err != nil {
+		return nil, err
+	}
+	if validator := RangeValidator(response.Header); validator != "" && !response.NotModified {
+		response.Body = &resumingBody{
+			d:         d,
+			ctx:       ctx,
+			url:       url,
+			body:      response.Body,
+			offset:    response.Offset,
+			validator: validator,
+			attempt:   attempt,
+		}
+	}
+	return response, nil
+}
+
+// download makes attempts, numbered from the given one, until one succeeds or
+// fails for good, and returns the number of the last one.
+func (d *RetryingDownloader) download(ctx context.Context, url string, attempt int) (*Response, int, error) {
+	for ; ; attempt++ {
+		response, err := d.Downloader.Download(ctx, url)
+		if err == nil || attempt >= d.Settings.MaxAttempts || !IsRetryable(err) || ctx.Err() != nil {
+			return response, attempt, err
+		}
+		delay, ok := d.delay(attempt, err)
+		if !ok {
+			return response, attempt, err
+		}
+		if sleepErr := sleep(ctx, delay); sleepErr != nil {
+			return nil, attempt, err
+		}
+	}
+}
+
+// resumingBody requests the rest of the document when reading its body fails
+// with a transient error.
+type resumingBody struct {
+	d         *RetryingDownloader
+	ctx       context.Context
+	url       string
+	body      io.ReadCloser
+	offset    int64 // position of body in the document
+	validator string
+	attempt   int
+}
+
+func (b *resumingBody) Read(p []byte) (int, error) {
+	for {
+		n, err := b.body.Read(p)
+		b.offset += int64(n)
+		if err == nil || err == io.EOF || !IsRetryable(err) || b.validator == "" ||
+			b.attempt >= b.d.Settings.MaxAttempts || b.ctx.Err() != nil {
+			return n, err
+		}
+		if resumeErr := b.resume(err); resumeErr != nil {
+			return n, fmt.Errorf("%w, resuming failed: %w", err, resumeErr)
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+func (b *resumingBody) resume(cause error) error {
+	_ = b.body.Close()
+	delay, ok := b.d.delay(b.attempt, cause)
+	if !ok {
+		return cause
+	}
+	if err := sleep(b.ctx, delay); err != nil {
+		return err
+	}
+
+	// Conditions of the caller were met by the first response already.
+	ctx := WithConditions(b.ctx, Conditions{})
+	ctx = WithRange(ctx, Range{Start: b.offset, IfRange: b.validator})
+	response, attempt, err := b.d.download(ctx, b.url, b.attempt+1)
+	b.attempt = attempt
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case b.offset == 0:
+		// Nothing was read yet, so any version will do.
+		b.validator = RangeValidator(response.Header)
+	case response.Offset == b.offset:
+	case response.Offset == 0 && RangeValidator(response.Header) == b.validator:
+		// The server ignores ranges, so skip what was read already.
+		if _, err := io.CopyN(io.Discard, response.Body, b.offset); err != nil {
+			_ = response.Body.Close()
+			return err
+		}
+	default:
+		_ = response.Body.Close()
+		return ErrChanged
+	}
+	b.body = response.Body
+	return nil
+}
+
+func (b *resumingBody) Close() error {
+	return b.body.Close()
+}
+
+// delay returns the pause before the attempt following the given one. It
+// reports false when Retry-After asks for more than MaxDelay.
+func (d *RetryingDownloader) delay(attempt int, err error) (time.Duration, bool) {
+	var downloadErr *DownloadError
+	if errors.As(err, &downloadErr) && downloadErr.RetryAfter > 0 {
+		if d.Settings.MaxDelay > 0 && downloadErr.RetryAfter > d.Settings.MaxDelay {
+			return 0, false
+		}
+		return downloadErr.RetryAfter, true
+	}
+
+	backoff := d.limit(d.Settings.BaseDelay << min(attempt-1, 30))
+	if backoff <= 0 {
+		return 0, true
+	}
+	return backoff/2 + rand.N(backoff/2+1), true
+}
+
+func (d *RetryingDownloader) limit(delay time.Duration) time.Duration {
+	if d.Settings.MaxDelay > 0 && (delay > d.Settings.MaxDelay || delay < 0) {
+		return d.Settings.MaxDelay
+	}
+	return delay
+}
+
+// IsRetryable reports whether err is a network error or an HTTP status that
+// may succeed on another attempt: 408, 429 and 5xx.
+func IsRetryable(err error) bool {
+	var downloadErr *DownloadError
+	if errors.As(err, &downloadErr) {
+		return downloadErr.StatusCode == http.StatusRequestTimeout ||
+			downloadErr.StatusCode == http.StatusTooManyRequests ||
+			downloadErr.StatusCode >= 500
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	// url.Error itself implements net.Error, so look at what it wraps.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, ErrReadTimeout) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,93 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"wget/downloader"
+	"wget/storage"
+)
+
+// Fetcher downloads single documents to files named the way wget names them,
+// without crawling.
+type Fetcher struct {
+	Downloader downloader.Downloader
+	FileSaver  storage.FileSaver
+	Output     io.Writer // optional, receives every document instead of a file
+}
+
+func NewFetcher(downloader downloader.Downloader, fileSaver storage.FileSaver, output io.Writer) *Fetcher {
+	return &Fetcher{
+		Downloader: downloader,
+		FileSaver:  fileSaver,
+		Output:     output,
+	}
+}
+
+// ErrNotModified is returned by Fetch when the server answered 304 Not
+// Modified; nothing is saved then.
+var ErrNotModified = errors.New("not modified, nothing saved")
+
+// Fetch downloads rawUrl and returns the name of the file it was saved to,
+// which is empty when the document was written to Output. An existing file is
+// never overwritten: a numeric suffix is appended instead.
+func (f *Fetcher) Fetch(ctx context.Context, rawUrl string) (string, error) {
+	response, err := f.Downloader.Download(ctx, rawUrl)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	if response.NotModified {
+		// A 304, e.g. for a user's If-Modified-Since header, has no body.
+		return "", ErrNotModified
+	}
+
+	if f.Output != nil {
+		_, err = io.Copy(f.Output, response.Body)
+		return "", err
+	}
+
+	name := f.unusedName(FileName(rawUrl, response.Header))
+	return name, f.FileSaver.Save(name, response.Body)
+}
+
+// unusedName returns name, or name.1, name.2 and so on if it is taken.
+func (f *Fetcher) unusedName(name string) string {
+	candidate := name
+	for i := 1; f.FileSaver.Exists(candidate); i++ {
+		candidate = fmt.Sprintf("%s.%d", name, i)
+	}
+	return candidate
+}
+
+// FileName returns the file name suggested by Content-Disposition, or else
+// the last segment of the URL path, or index.html.
+func FileName(rawUrl string, header http.Header) string {
+	if _, params, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil {
+		if name := baseName(params["filename"]); name != "" {
+			return name
+		}
+	}
+	if u, err := url.Parse(rawUrl); err == nil {
+		if name := baseName(u.Path); name != "" {
+			return name
+		}
+	}
+	return "index.html"
+}
+
+// baseName strips directories from a suggested name, so that a server cannot
+// make the file land outside of the output directory.
+func baseName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == ".." || name == "/" {
+		return ""
+	}
+	return name
+}
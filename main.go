@@ -2,41 +2,511 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
 	"log"
+	"maps"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+	"wget/cookies"
 	"wget/downloader"
+	"wget/fetcher"
 	"wget/parser"
 	"wget/pathmapper"
+	"wget/robots"
+	"wget/sitemap"
 	"wget/storage"
 	"wget/webcrawler"
 )
 
+// fetch downloads urls in download mode and returns the exit code.
+func fetch(d downloader.Downloader, outputDocument, prefix string, urls []string) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	f := fetcher.NewFetcher(d, &storage.OsFileSaver{OutputDir: prefix}, nil)
+	switch outputDocument {
+	case "":
+	case "-":
+		f.Output = os.Stdout
+	default:
+		file, err := os.Create(outputDocument)
+		if err != nil {
+			log.Print(err)
+			return 1
+		}
+		defer func() {
+			if err := file.Close(); err != nil {
+				log.Print(err)
+			}
+		}()
+		f.Output = file
+	}
+
+	code := 0
+	for _, rawUrl := range urls {
+		name, err := f.Fetch(ctx, rawUrl)
+		if errors.Is(err, fetcher.ErrNotModified) {
+			log.Printf("%s is %v", rawUrl, err)
+			continue
+		}
+		if err != nil {
+			log.Printf("Download of %s failed: %v", rawUrl, err)
+			code = 1
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		if name != "" {
+			log.Printf("Saved %s as %s", rawUrl, filepath.Join(prefix, name))
+		}
+	}
+	return code
+}
+
+// readSeeds reads the seed URLs of -i from a file or, for "-", stdin.
+func readSeeds(name string, forceHTML bool, base string) ([]string, error) {
+	input := os.Stdin
+	if name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		input = file
+	}
+
+	if !forceHTML {
+		return webcrawler.ReadSeeds(input)
+	}
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	return webcrawler.SeedsFromHTML(data, base)
+}
+
+// loadCookies adds the cookies of a cookies.txt file to jar.
+func loadCookies(jar *cookies.Jar, name string) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return jar.Load(file)
+}
+
+// saveCookies writes jar to a cookies.txt file readable only by the user.
+func saveCookies(jar *cookies.Jar, name string, keepSession bool) error {
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := jar.Save(file, keepSession); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// loadNetrc reads the credentials of a .netrc file. A missing file is not
+// an error unless it was named explicitly.
+func loadNetrc(name string, explicit bool) (map[string]downloader.Credentials, error) {
+	file, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return downloader.ParseNetrc(file)
+}
+
+func defaultNetrc() string {
+	if name := os.Getenv("NETRC"); name != "" {
+		return name
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+// headerFlag collects repeated -header "Name: value" flags.
+type headerFlag http.Header
+
+func (h headerFlag) String() string {
+	return fmt.Sprint(http.Header(h))
+}
+
+func (h headerFlag) Set(value string) error {
+	name, val, ok := strings.Cut(value, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("invalid header %q, expected \"Name: value\"", value)
+	}
+	http.Header(h).Add(strings.TrimSpace(name), strings.TrimSpace(val))
+	return nil
+}
+
+// pairFlag collects repeated "key=value" flags such as -bearer. String
+// lists the keys only, so that secrets are not printed.
+type pairFlag map[string]string
+
+func (p pairFlag) String() string {
+	keys := make([]string, 0, len(p))
+	for key := range p {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, ",")
+}
+
+func (p pairFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(key) == "" {
+		return fmt.Errorf("invalid value %q, expected \"key=value\"", value)
+	}
+	p[strings.TrimSpace(key)] = val
+	return nil
+}
+
+// hostsOf returns the hosts, with ports, of urls.
+func hostsOf(urls []string) []string {
+	var hosts []string
+	for _, rawUrl := range urls {
+		if u, err := neturl.Parse(rawUrl); err == nil && u.Host != "" {
+			hosts = append(hosts, u.Host)
+		}
+	}
+	return hosts
+}
+
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+	return set
+}
+
+// compileOptional compiles a regexp flag, nil when it is empty.
+func compileOptional(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func main() {
+	header := headerFlag{}
+	bearer := pairFlag{}
+	loginFields := pairFlag{}
 	var (
-		url    = flag.String("url", "", "URL to mirror")
-		depth  = flag.Int("depth", 3, "Max depth for recursion")
-		output = flag.String("output", "./mirror", "Output directory")
+		url       = flag.String("url", "", "URL to mirror")
+		inputFile = flag.String("i", "", "File with seed URLs to mirror, one per line, \"-\" for stdin")
+		forceHTML = flag.Bool("force-html", false, "Treat the -i file as HTML and mirror the pages it links to")
+		base      = flag.String("base", "", "Base URL for relative links in the -force-html file")
+		outputDoc = flag.String("O", "", "Write the downloaded URLs to this file, \"-\" for stdout (download mode)")
+		prefix    = flag.String("P", ".", "Directory to save the downloaded URLs to (download mode)")
+		depth     = flag.Int("depth", 3, "Max depth for recursion")
+		output    = flag.String("output", "./mirror", "Output directory")
+		workers   = flag.Int("workers", 4, "Number of concurrent download workers")
+		convert   = flag.Bool("convert-links", false, "Make links in downloaded HTML point to local files")
+		resume    = flag.Bool("continue", false, "Continue an interrupted mirror from its state file")
+		timestamp = flag.Bool("timestamping", false, "Re-download only documents changed since the last mirror")
+		robotsTxt = flag.String("robots", "on", "Respect robots.txt: on or off")
+		sitemaps  = flag.Bool("sitemaps", false, "Also crawl the pages listed in the site's sitemaps")
+		since     = flag.String("since", "", "Skip sitemap pages not modified after this date (YYYY-MM-DD or RFC 3339)")
+
+		spanHosts      = flag.Bool("span-hosts", false, "Follow links to other hosts")
+		sameDomain     = flag.Bool("same-domain", false, "Follow links to any host of the seed's registrable domain")
+		domains        = flag.String("domains", "", "Comma-separated hosts to follow links to, with their subdomains")
+		excludeDomains = flag.String("exclude-domains", "", "Comma-separated hosts never to follow links to")
+		noParent       = flag.Bool("no-parent", false, "Don't ascend above the directory of the seed URL")
+		requisites     = flag.Bool("page-requisites", false, "Also fetch images, styles and scripts of pages from other hosts")
+		requisiteHosts = flag.String("requisite-hosts", "", "Comma-separated hosts -page-requisites may fetch from, e.g. CDNs, empty for any")
+
+		accept             = flag.String("A", "", "Comma-separated extensions or file name globs to save")
+		reject             = flag.String("R", "", "Comma-separated extensions or file name globs not to save")
+		includeDirectories = flag.String("include-directories", "", "Comma-separated directories to crawl, globs allowed")
+		excludeDirectories = flag.String("exclude-directories", "", "Comma-separated directories not to crawl, globs allowed")
+		acceptRegex        = flag.String("accept-regex", "", "Regexp the canonical URL must match")
+		rejectRegex        = flag.String("reject-regex", "", "Regexp the canonical URL must not match")
+		acceptTypes        = flag.String("accept-type", "", "Comma-separated MIME types to save, e.g. text/html,image/*")
+		rejectTypes        = flag.String("reject-type", "", "Comma-separated MIME types not to save")
+
+		sortQuery   = flag.Bool("sort-query", false, "Treat URLs differing only in query parameter order as one")
+		stripParams = flag.String("strip-params", strings.Join(webcrawler.DefaultTrackingParams, ","), "Comma-separated query parameters to drop, \"*\" suffix matches a prefix")
+
+		wait            = flag.Duration("wait", 0, "Min delay between requests to one host")
+		randomWait      = flag.Bool("random-wait", false, "Vary -wait from 0.5 to 1.5 times")
+		hostRate        = flag.Float64("host-rate", 0, "Max requests per second to one host, 0 for no limit")
+		hostConnections = flag.Int("host-connections", 0, "Max concurrent connections to one host, 0 for no limit")
+
+		noHostDirs   = flag.Bool("nH", false, "Don't create host directories in the output, except for requisites from other sites")
+		protocolDirs = flag.Bool("protocol-directories", false, "Put host directories under http or https directories")
+		cutDirs      = flag.Int("cut-dirs", 0, "Drop this many leading directories of URL paths")
+		noDirs       = flag.Bool("nd", false, "Save all files into the output directory, numbering files with the same name")
+
+		checkpointInterval = flag.Duration("checkpoint-interval", 30*time.Second, "How often to save the crawl state")
+
+		timeout        = flag.Duration("timeout", 0, "Overall timeout for a single download, 0 for none")
+		connectTimeout = flag.Duration("connect-timeout", 30*time.Second, "Timeout for establishing a connection")
+		readTimeout    = flag.Duration("read-timeout", 15*time.Minute, "Timeout for an idle connection")
+		userAgent      = flag.String("user-agent", downloader.DefaultUserAgent, "User-Agent header")
+		proxy          = flag.String("proxy", "", "HTTP(S) proxy URL, defaults to HTTP_PROXY/HTTPS_PROXY")
+		caCertificate  = flag.String("ca-certificate", "", "PEM file with additional trusted CA certificates")
+		noCheckCert    = flag.Bool("no-check-certificate", false, "Don't verify TLS certificates")
+		maxRedirect    = flag.Int("max-redirect", 20, "Max number of redirects to follow, 0 to disable")
+		tries          = flag.Int("tries", 3, "Number of attempts for transient failures")
+		retryDelay     = flag.Duration("retry-delay", time.Second, "Initial delay between attempts, doubled after each one")
+		limitRate      = flag.String("limit-rate", "0", "Max total download rate in bytes per second, e.g. 500k or 2M, 0 for no limit")
+		connLimitRate  = flag.String("connection-limit-rate", "0", "Max download rate of every connection, e.g. 100k, 0 for no limit")
+		maxRetryDelay  = flag.Duration("max-retry-delay", 30*time.Second, "Max delay between attempts; a longer Retry-After is not retried")
+
+		loadCookiesFile    = flag.String("load-cookies", "", "Load cookies from a Netscape cookies.txt file")
+		saveCookiesFile    = flag.String("save-cookies", "", "Save cookies to a Netscape cookies.txt file when done")
+		keepSessionCookies = flag.Bool("keep-session-cookies", false, "Also save session cookies with -save-cookies")
+
+		httpUser     = flag.String("http-user", "", "User name for Basic and Digest auth on the hosts of the given URLs")
+		httpPassword = flag.String("http-password", "", "Password for -http-user")
+		netrcFile    = flag.String("netrc", defaultNetrc(), "File with credentials by host, empty to disable")
+
+		loginURL      = flag.String("login-url", "", "Page with a login form to submit before mirroring")
+		logoutPattern = flag.String("logout-pattern", webcrawler.DefaultLogoutPattern.String(), "Regexp for URLs and link attributes of logout links, which are not followed after -login-url")
 	)
+	flag.Var(header, "header", "Extra request header \"Name: value\", may be repeated")
+	flag.Var(bearer, "bearer", "Bearer token sent to one host, \"host=token\", may be repeated")
+	flag.Var(loginFields, "login-field", "Login form field \"name=value\", e.g. username=alice, may be repeated")
 	flag.Parse()
 
-	if *url == "" {
-		log.Fatal("URL is required")
+	if *url == "" && *inputFile == "" && flag.NArg() == 0 {
+		log.Fatal("URL is required: -url or -i to mirror sites, or URLs as arguments to download them")
+	}
+	if flag.NArg() > 0 && (*url != "" || *inputFile != "") {
+		log.Fatal("URLs as arguments are downloaded without crawling, so they cannot be combined with -url or -i")
+	}
+
+	// URLs given as arguments are downloaded on their own, without crawling.
+	downloadMode := flag.NArg() > 0
+	seeds := flag.Args()
+	if !downloadMode {
+		if *url != "" {
+			seeds = append(seeds, *url)
+		}
+		if *inputFile != "" {
+			listed, err := readSeeds(*inputFile, *forceHTML, *base)
+			if err != nil {
+				log.Fatalf("Reading %s failed: %v", *inputFile, err)
+			}
+			seeds = append(seeds, listed...)
+		}
+	}
+
+	credentials := map[string]downloader.Credentials{}
+	if *netrcFile != "" {
+		entries, err := loadNetrc(*netrcFile, isFlagSet("netrc"))
+		if err != nil {
+			log.Fatalf("Reading %s failed: %v", *netrcFile, err)
+		}
+		maps.Copy(credentials, entries)
+	}
+	// -http-user is scoped to the hosts of the given URLs, never to hosts
+	// a crawl or a redirect leads to.
+	if *httpUser != "" {
+		for _, host := range hostsOf(seeds) {
+			credentials[host] = downloader.Credentials{Username: *httpUser, Password: *httpPassword}
+		}
+	}
+
+	// Zero in HTTPDownloaderSettings means the net/http default.
+	maxRedirects := *maxRedirect
+	if maxRedirects == 0 {
+		maxRedirects = -1
+	}
+
+	jar := cookies.NewJar()
+	if *loadCookiesFile != "" {
+		if err := loadCookies(jar, *loadCookiesFile); err != nil {
+			log.Fatalf("Loading cookies failed: %v", err)
+		}
+	}
+	// Cookies are saved on every exit path after the downloads.
+	flushCookies := func() {
+		if *saveCookiesFile == "" {
+			return
+		}
+		if err := saveCookies(jar, *saveCookiesFile, *keepSessionCookies); err != nil {
+			log.Printf("Saving cookies failed: %v", err)
+		}
 	}
 
-	downloader := &downloader.HTTPDownloader{} // реализация будет ниже
-	parser := &parser.HtmlParser{}
-	pathMapper := &pathmapper.FilePathMapper{}
+	httpDownloader, err := downloader.NewHTTPDownloader(downloader.HTTPDownloaderSettings{
+		ConnectTimeout:     *connectTimeout,
+		ReadTimeout:        *readTimeout,
+		Timeout:            *timeout,
+		UserAgent:          *userAgent,
+		Header:             http.Header(header),
+		ProxyURL:           *proxy,
+		CACertFile:         *caCertificate,
+		InsecureSkipVerify: *noCheckCert,
+		MaxRedirects:       maxRedirects,
+		Jar:                jar,
+		Credentials:        credentials,
+		BearerTokens:       bearer,
+	})
+	if err != nil {
+		log.Fatalf("Invalid HTTP settings: %v", err)
+	}
+	retryingDownloader := downloader.NewRetryingDownloader(httpDownloader, downloader.RetrySettings{
+		MaxAttempts: *tries,
+		BaseDelay:   *retryDelay,
+		MaxDelay:    *maxRetryDelay,
+	})
+	totalRate, err := downloader.ParseRate(*limitRate)
+	if err != nil {
+		log.Fatalf("Invalid -limit-rate: %v", err)
+	}
+	connectionRate, err := downloader.ParseRate(*connLimitRate)
+	if err != nil {
+		log.Fatalf("Invalid -connection-limit-rate: %v", err)
+	}
+	downloader := downloader.NewThrottledDownloader(retryingDownloader, downloader.BandwidthSettings{
+		LimitRate:           totalRate,
+		ConnectionLimitRate: connectionRate,
+	})
+	if downloadMode {
+		code := fetch(downloader, *outputDoc, *prefix, seeds)
+		flushCookies()
+		os.Exit(code)
+	}
+
+	parsers := parser.DefaultRegistry()
+	pathMapper := &pathmapper.FilePathMapper{
+		NoHostDirectories:   *noHostDirs,
+		ProtocolDirectories: *protocolDirs,
+		CutDirs:             *cutDirs,
+		NoDirectories:       *noDirs,
+	}
 	saver := &storage.OsFileSaver{OutputDir: *output}
 
+	if *robotsTxt != "on" && *robotsTxt != "off" {
+		log.Fatalf("Invalid -robots value %q, expected on or off", *robotsTxt)
+	}
+	var modifiedSince time.Time
+	if *since != "" {
+		if modifiedSince, err = sitemap.ParseLastMod(*since); err != nil {
+			log.Fatalf("Invalid -since value: %v", err)
+		}
+	}
+
+	scope := webcrawler.Scope{
+		SpanHosts:  *spanHosts,
+		AllowHosts: splitList(*domains),
+		DenyHosts:  splitList(*excludeDomains),
+		NoParent:   *noParent,
+	}
+	if *sameDomain {
+		scope.Policy = webcrawler.SameDomain
+	}
+
+	filter := webcrawler.Filter{
+		Accept:             splitList(*accept),
+		Reject:             splitList(*reject),
+		IncludeDirectories: splitList(*includeDirectories),
+		ExcludeDirectories: splitList(*excludeDirectories),
+		AcceptTypes:        splitList(*acceptTypes),
+		RejectTypes:        splitList(*rejectTypes),
+	}
+	if filter.AcceptRegex, err = compileOptional(*acceptRegex); err != nil {
+		log.Fatalf("Invalid -accept-regex: %v", err)
+	}
+	if filter.RejectRegex, err = compileOptional(*rejectRegex); err != nil {
+		log.Fatalf("Invalid -reject-regex: %v", err)
+	}
+
 	settings := webcrawler.WebCrawlerSettings{
 		MaxDepth:   *depth,
-		MaxWorkers: 0, // not implemented
+		MaxWorkers: *workers,
+		Scope:      scope,
+		Filter:     filter,
+		Politeness: webcrawler.Politeness{
+			RequestsPerSecond:     *hostRate,
+			MinDelay:              *wait,
+			RandomWait:            *randomWait,
+			MaxConnectionsPerHost: *hostConnections,
+		},
+		Canonicalizer: webcrawler.Canonicalizer{
+			SortQuery:   *sortQuery,
+			StripParams: splitList(*stripParams),
+		},
+		PageRequisites: *requisites,
+		RequisiteHosts: splitList(*requisiteHosts),
+		ModifiedSince:  modifiedSince,
+		ConvertLinks:   *convert,
+		Timestamping:   *timestamp,
+		Logger:         log.Default(),
+
+		StateFile:          ".wget-state.json",
+		CheckpointInterval: *checkpointInterval,
+		Continue:           *resume,
+	}
+
+	// Sitemap lines of robots.txt are used even when its rules are ignored.
+	robotsChecker := robots.NewChecker(downloader, *userAgent)
+	if *robotsTxt == "on" {
+		settings.Robots = robotsChecker
 	}
+	if *sitemaps {
+		settings.Sitemaps = sitemap.NewCollector(downloader, robotsChecker)
+	}
+	if *loginURL != "" {
+		logout, err := regexp.Compile(*logoutPattern)
+		if err != nil {
+			log.Fatalf("Invalid -logout-pattern: %v", err)
+		}
+		// The form is posted by the HTTP downloader itself, so that the
+		// session cookies land in the jar that all downloads share.
+		settings.Login = &webcrawler.Login{
+			URL:       *loginURL,
+			Fields:    loginFields,
+			Submitter: httpDownloader,
+			Logout:    logout,
+		}
+	}
+
+	crawler := webcrawler.NewWebCrawler(downloader, parsers, pathMapper, saver, settings)
 
-	crawler := webcrawler.NewWebCrawler(downloader, parser, pathMapper, saver, settings)
+	// Stop gracefully on Ctrl-C so that the final checkpoint is written.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	result, err := crawler.Mirror(context.Background(), *url)
+	result, err := crawler.Mirror(ctx, seeds...)
+	flushCookies()
 	if err != nil {
 		log.Fatalf("Mirror failed: %v", err)
 	}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+type attribute struct {
+	key        string
+	start, end int
+	hasValue   bool
+}
+
+func (a attribute) value(raw []byte) string {
+	return html.UnescapeString(string(raw[a.start:a.end]))
+}
+
+type attributes struct {
+	nameEnd int
+	list    []attribute
+}
+
+// scanAttributes splits a raw start tag into its attributes, keeping the byte
+// span of each value without quotes so that callers can rewrite it in place.
+func scanAttributes(raw []byte) attributes {
+	var attrs attributes
+	i := 1
+	for i < len(raw) && !isTagSpace(raw[i]) && raw[i] != '>' && raw[i] != '/' {
+		i++
+	}
+	attrs.nameEnd = i
+
+	for i < len(raw) {
+		for i < len(raw) && (isTagSpace(raw[i]) || raw[i] == '/') {
+			i++
+		}
+		if i >= len(raw) || raw[i] == '>' {
+			break
+		}
+
+		keyStart := i
+		for i < len(raw) && !isTagSpace(raw[i]) && raw[i] != '=' && raw[i] != '>' && (raw[i] != '/' || i == keyStart) {
+			i++
+		}
+		attr := attribute{key: strings.ToLower(string(raw[keyStart:i]))}
+
+		j := i
+		for j < len(raw) && isTagSpace(raw[j]) {
+			j++
+		}
+		if j >= len(raw) || raw[j] != '=' {
+			attrs.list = append(attrs.list, attr)
+			continue
+		}
+		i = j + 1
+		for i < len(raw) && isTagSpace(raw[i]) {
+			i++
+		}
+
+		attr.hasValue = true
+		if i < len(raw) && (raw[i] == '"' || raw[i] == '\'') {
+			quote := raw[i]
+			i++
+			attr.start = i
+			for i < len(raw) && raw[i] != quote {
+				i++
+			}
+			attr.end = i
+			i++
+		} else {
+			attr.start = i
+			for i < len(raw) && !isTagSpace(raw[i]) && raw[i] != '>' {
+				i++
+			}
+			attr.end = i
+		}
+		attrs.list = append(attrs.list, attr)
+	}
+	return attrs
+}
+
+// values maps attribute names to their unescaped values, keeping the first
+// of repeated attributes.
+func (a attributes) values(raw []byte) map[string]string {
+	values := make(map[string]string, len(a.list))
+	for _, attr := range a.list {
+		if _, ok := values[attr.key]; !ok {
+			values[attr.key] = attr.value(raw)
+		}
+	}
+	return values
+}
+
+func isTagSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f'
+}
@@ -0,0 +1,119 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+)
+
+// CssParser extracts url() and @import references from stylesheets.
+type CssParser struct{}
+
+func (p *CssParser) Parse(data []byte) ([]Reference, error) {
+	return p.ParseCSS(data)
+}
+
+// ParseCSS returns references with Tag set to the CSS construct, "url" or
+// "@import", and offsets pointing at the URL without quotes.
+func (p *CssParser) ParseCSS(data []byte) ([]Reference, error) {
+	refs := make([]Reference, 0)
+	for i := 0; i < len(data); {
+		switch {
+		case bytes.HasPrefix(data[i:], []byte("/*")):
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				return refs, nil
+			}
+			i += end + 4
+		case data[i] == '"' || data[i] == '\'':
+			_, _, i = scanCssString(data, i)
+		case hasPrefixFold(data[i:], "@import"):
+			i += len("@import")
+			for i < len(data) && isCssSpace(data[i]) {
+				i++
+			}
+			if i < len(data) && (data[i] == '"' || data[i] == '\'') {
+				var start, end int
+				start, end, i = scanCssString(data, i)
+				refs = appendCssReference(refs, data, "@import", start, end)
+			} else if hasPrefixFold(data[i:], "url(") {
+				var start, end int
+				start, end, i = scanCssUrl(data, i+len("url("))
+				refs = appendCssReference(refs, data, "@import", start, end)
+			}
+		case hasPrefixFold(data[i:], "url(") && (i == 0 || !isCssNameByte(data[i-1])):
+			var start, end int
+			start, end, i = scanCssUrl(data, i+len("url("))
+			refs = appendCssReference(refs, data, "url", start, end)
+		default:
+			i++
+		}
+	}
+	return refs, nil
+}
+
+func appendCssReference(refs []Reference, data []byte, tag string, start, end int) []Reference {
+	value := strings.TrimSpace(string(data[start:end]))
+	if value == "" || strings.HasPrefix(value, "#") || isIgnoredScheme(value) {
+		return refs
+	}
+	return append(refs, Reference{
+		URL:   value,
+		Tag:   tag,
+		Kind:  KindRequisite,
+		Start: start,
+		End:   end,
+	})
+}
+
+// scanCssUrl scans the argument of url( starting at i and returns the span of
+// the URL and the position after the closing parenthesis.
+func scanCssUrl(data []byte, i int) (start, end, next int) {
+	for i < len(data) && isCssSpace(data[i]) {
+		i++
+	}
+	if i < len(data) && (data[i] == '"' || data[i] == '\'') {
+		start, end, i = scanCssString(data, i)
+	} else {
+		start = i
+		for i < len(data) && data[i] != ')' && !isCssSpace(data[i]) {
+			if data[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		end = min(i, len(data))
+	}
+	for i < len(data) && data[i] != ')' {
+		i++
+	}
+	return start, end, i + 1
+}
+
+// scanCssString scans a quoted string starting at i and returns the span of
+// its contents and the position after the closing quote.
+func scanCssString(data []byte, i int) (start, end, next int) {
+	quote := data[i]
+	i++
+	start = i
+	for i < len(data) && data[i] != quote && data[i] != '\n' {
+		if data[i] == '\\' {
+			i++
+		}
+		i++
+	}
+	end = min(i, len(data))
+	return start, end, i + 1
+}
+
+func hasPrefixFold(data []byte, prefix string) bool {
+	return len(data) >= len(prefix) && strings.EqualFold(string(data[:len(prefix)]), prefix)
+}
+
+func isCssSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f'
+}
+
+func isCssNameByte(b byte) bool {
+	return b == '-' || b == '_' || b >= 0x80 ||
+		'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9'
+}
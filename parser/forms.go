@@ -0,0 +1,88 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Form is an HTML form with the fields a browser would submit.
+type Form struct {
+	Action string // raw action attribute, empty for the document URL
+	Method string // lower case, "get" when absent
+	Fields []FormField
+}
+
+// FormField is an <input> or <button> of a form.
+type FormField struct {
+	Name    string
+	Type    string // lower case, "text" when absent
+	Value   string
+	Checked bool
+}
+
+// HasPassword reports whether the form has a password field, as login
+// forms do.
+func (f *Form) HasPassword() bool {
+	for _, field := range f.Fields {
+		if field.Type == "password" {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseForms lists the forms of an HTML document with their <input> and
+// <button> fields. Fields outside of a form are ignored.
+func (p *HtmlParser) ParseForms(data []byte) []Form {
+	var forms []Form
+	var current *Form
+	tokenizer := html.NewTokenizer(bytes.NewReader(data))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			return forms
+		}
+		raw := tokenizer.Raw()
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tag, values := tagAttributes(raw)
+			switch {
+			case tag == "form":
+				forms = append(forms, Form{Action: values["action"], Method: strings.ToLower(values["method"])})
+				current = &forms[len(forms)-1]
+				if current.Method == "" {
+					current.Method = "get"
+				}
+			case current != nil && (tag == "input" || tag == "button"):
+				field := FormField{
+					Name:    values["name"],
+					Type:    strings.ToLower(values["type"]),
+					Value:   values["value"],
+					Checked: hasKey(values, "checked"),
+				}
+				if field.Type == "" {
+					field.Type = map[string]string{"input": "text", "button": "submit"}[tag]
+				}
+				current.Fields = append(current.Fields, field)
+			}
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == "form" {
+				current = nil
+			}
+		}
+	}
+}
+
+// tagAttributes returns the lower case name of a start tag and its
+// attribute values.
+func tagAttributes(raw []byte) (string, map[string]string) {
+	attrs := scanAttributes(raw)
+	return strings.ToLower(string(raw[1:attrs.nameEnd])), attrs.values(raw)
+}
+
+func hasKey(values map[string]string, key string) bool {
+	_, ok := values[key]
+	return ok
+}
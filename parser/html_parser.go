@@ -2,71 +2,193 @@ package parser
 
 import (
 	"bytes"
-	"golang.org/x/net/html"
 	"strings"
+
+	"golang.org/x/net/html"
 )
 
 type Parser interface {
-	ParseHTML(data []byte) (resources []string, links []string, err error)
+	Parse(data []byte) ([]Reference, error)
 }
 
-type HtmlParser struct{}
+// Kind tells the crawler how a referenced URL relates to the document.
+type Kind int
 
-func (p *HtmlParser) ParseHTML(data []byte) ([]string, []string, error) {
-	document, err := html.Parse(bytes.NewReader(data))
-	if err != nil {
-		return nil, nil, err
-	}
-	resources, links := make([]string, 0), make([]string, 0)
-	resources, links = p.parse(document, resources, links)
-	return resources, links, nil
+const (
+	// KindPage is a hyperlink to another document, followed recursively.
+	KindPage Kind = iota
+	// KindRequisite is a resource needed to render the document.
+	KindRequisite
+	// KindEmbedded is a document or media embedded into the document.
+	KindEmbedded
+)
+
+// Reference is a URL found in a document. For references found in CSS, Tag
+// is the CSS construct, "url" or "@import", and Attr is "style" inside a
+// style attribute and empty otherwise.
+type Reference struct {
+	URL   string
+	Tag   string
+	Attr  string
+	Kind  Kind
+	Start int // byte offset of the raw URL in the source, without quotes
+	End   int
+	Attrs map[string]string
 }
 
-func (p *HtmlParser) parse(node *html.Node, resources []string, links []string) ([]string, []string) {
-	if node.Type == html.ElementNode {
-		switch node.Data {
-		case "a":
-			for _, attr := range node.Attr {
-				if attr.Key == "href" && !isIgnoredScheme(attr.Val) {
-					links = append(links, attr.Val)
-				}
-			}
-		case "img":
-			for _, attr := range node.Attr {
-				if attr.Key == "src" && !isIgnoredScheme(attr.Val) {
-					resources = append(resources, attr.Val)
-				}
-			}
-		case "script":
-			for _, attr := range node.Attr {
-				if attr.Key == "src" {
-					resources = append(resources, attr.Val)
-				}
-			}
-		case "link":
-			for _, attr := range node.Attr {
-				if attr.Key == "href" && !isIgnoredScheme(attr.Val) {
-					resources = append(resources, attr.Val)
-				}
-			}
-		case "iframe":
-			for _, attr := range node.Attr {
-				if attr.Key == "src" && !isIgnoredScheme(attr.Val) {
-					resources = append(resources, attr.Val)
-				}
+// HtmlParser also reports references from inline <style> blocks and style
+// attributes, and parses standalone stylesheets through the embedded CssParser.
+type HtmlParser struct {
+	CssParser
+}
+
+// references lists the tags and attributes that refer to other URLs.
+var references = map[string]struct {
+	attr string
+	kind Kind
+}{
+	"a":      {"href", KindPage},
+	"img":    {"src", KindRequisite},
+	"script": {"src", KindRequisite},
+	"link":   {"href", KindRequisite}, // by rel, see linkKind
+	"iframe": {"src", KindEmbedded},
+	"video":  {"src", KindEmbedded},
+	"audio":  {"src", KindEmbedded},
+}
+
+func (p *HtmlParser) Parse(data []byte) ([]Reference, error) {
+	return p.ParseHTML(data)
+}
+
+func (p *HtmlParser) ParseHTML(data []byte) ([]Reference, error) {
+	refs := make([]Reference, 0)
+	tokenizer := html.NewTokenizer(bytes.NewReader(data))
+	offset := 0
+	style := false
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+		raw := tokenizer.Raw()
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			var tag string
+			tag, refs = p.parseTag(raw, offset, refs)
+			style = tag == "style" && tokenType == html.StartTagToken
+		case html.TextToken:
+			if style {
+				refs = p.parseStyle(raw, offset, "", refs)
 			}
-		case "video", "audio":
-			for _, attr := range node.Attr {
-				if attr.Key == "src" && !isIgnoredScheme(attr.Val) {
-					resources = append(resources, attr.Val)
+			style = false
+		default:
+			style = false
+		}
+		offset += len(raw)
+	}
+	return refs, nil
+}
+
+func (p *HtmlParser) parseTag(raw []byte, offset int, refs []Reference) (string, []Reference) {
+	attrs := scanAttributes(raw)
+	tag := strings.ToLower(string(raw[1:attrs.nameEnd]))
+	ref, hasRef := references[tag]
+
+	values := attrs.values(raw)
+	if tag == "link" {
+		ref.kind, hasRef = linkKind(values["rel"])
+	}
+
+	for _, attr := range attrs.list {
+		if !attr.hasValue {
+			continue
+		}
+		if attr.key == "style" {
+			refs = p.parseStyle(raw[attr.start:attr.end], offset+attr.start, attr.key, refs)
+			continue
+		}
+		if !hasRef || attr.key != ref.attr {
+			continue
+		}
+		hasRef = false
+		value := attr.value(raw)
+		if !isIgnoredScheme(value) {
+			refs = append(refs, Reference{
+				URL:   value,
+				Tag:   tag,
+				Attr:  attr.key,
+				Kind:  ref.kind,
+				Start: offset + attr.start,
+				End:   offset + attr.end,
+				Attrs: values,
+			})
+		}
+	}
+	return tag, refs
+}
+
+// parseStyle adds references from CSS embedded into the document at offset.
+// attr is empty for <style> blocks and "style" for style attributes, whose
+// raw values may contain character references.
+func (p *HtmlParser) parseStyle(raw []byte, offset int, attr string, refs []Reference) []Reference {
+	css, positions := raw, []int(nil)
+	if attr != "" {
+		css, positions = unescapeWithPositions(raw)
+	}
+	cssRefs, _ := p.ParseCSS(css)
+	for _, ref := range cssRefs {
+		if positions != nil {
+			ref.Start, ref.End = positions[ref.Start], positions[ref.End]
+		}
+		ref.Attr = attr
+		ref.Start += offset
+		ref.End += offset
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// unescapeWithPositions decodes character references in raw and returns, for
+// every byte of the result and one past its end, the matching offset in raw.
+func unescapeWithPositions(raw []byte) ([]byte, []int) {
+	out := make([]byte, 0, len(raw))
+	positions := make([]int, 0, len(raw)+1)
+	for i := 0; i < len(raw); {
+		if raw[i] == '&' {
+			if end := bytes.IndexByte(raw[i:min(len(raw), i+32)], ';'); end > 0 {
+				decoded := html.UnescapeString(string(raw[i : i+end+1]))
+				for range len(decoded) {
+					positions = append(positions, i)
 				}
+				out = append(out, decoded...)
+				i += end + 1
+				continue
 			}
 		}
+		out = append(out, raw[i])
+		positions = append(positions, i)
+		i++
 	}
-	for child := node.FirstChild; child != nil; child = child.NextSibling {
-		resources, links = p.parse(child, resources, links)
+	return out, append(positions, len(raw))
+}
+
+// linkKind classifies a <link> by its rel. Resources the page loads are
+// requisites, hints that only warm up connections are ignored, and other
+// relations such as next or canonical point to pages.
+func linkKind(rel string) (Kind, bool) {
+	tokens := strings.Fields(strings.ToLower(rel))
+	for _, token := range tokens {
+		switch token {
+		case "stylesheet", "icon", "apple-touch-icon", "preload", "modulepreload", "manifest":
+			return KindRequisite, true
+		}
+	}
+	for _, token := range tokens {
+		if token == "dns-prefetch" || token == "preconnect" {
+			return KindPage, false
+		}
 	}
-	return resources, links
+	return KindPage, true
 }
 
 func isIgnoredScheme(value string) bool {
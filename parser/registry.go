@@ -0,0 +1,39 @@
+package parser
+
+import (
+	"mime"
+	"strings"
+)
+
+// Registry selects a parser by the MIME type of a response. Content without a
+// registered parser, such as images or archives, is never parsed.
+type Registry struct {
+	parsers map[string]Parser
+}
+
+func NewRegistry() *Registry {
+	return &Registry{parsers: map[string]Parser{}}
+}
+
+// DefaultRegistry returns a registry with the HTML and CSS parsers.
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.Register("text/html", &HtmlParser{})
+	r.Register("application/xhtml+xml", &HtmlParser{})
+	r.Register("text/css", &CssParser{})
+	return r
+}
+
+func (r *Registry) Register(mediaType string, parser Parser) {
+	r.parsers[strings.ToLower(mediaType)] = parser
+}
+
+// Lookup returns the parser for a Content-Type header value.
+func (r *Registry) Lookup(contentType string) (Parser, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+	parser, ok := r.parsers[mediaType]
+	return parser, ok
+}
@@ -1,40 +1,152 @@
 package pathmapper
 
 import (
+	"fmt"
+	"maps"
+	"net"
 	"net/url"
 	"path"
 	"strings"
+	"sync"
 )
 
 type PathMapper interface {
 	Map(url string) string
 }
 
-type FilePathMapper struct{}
+// HostPathMapper is a PathMapper that can keep the host directory of a URL
+// even when it drops host directories otherwise, e.g. for files of another
+// site that would overwrite those of the mirrored one.
+type HostPathMapper interface {
+	PathMapper
+	MapWithHost(url string) string
+}
+
+// AssigningPathMapper is a PathMapper whose files depend on the order in which
+// URLs are mapped. A crawl saves the assignments with its state and restores
+// them on the next run, so that every document keeps its file.
+type AssigningPathMapper interface {
+	PathMapper
+	Assignments() map[string]string
+	Restore(assignments map[string]string)
+}
+
+// FilePathMapper lays files out the way wget does. By default a URL is saved
+// under a directory named after its host, with the port when it is not the
+// default one: https://example.com:8443/docs/ maps to
+// example.com:8443/docs/index.html.
+type FilePathMapper struct {
+	NoHostDirectories   bool // drop the host directory
+	ProtocolDirectories bool // put the host directory under "http" or "https"
+	CutDirs             int  // drop this many leading directories of the path
+	// NoDirectories saves every file into the top directory. Files with the
+	// same name are numbered in the order they are mapped: index.html,
+	// index.html.1 and so on. Assignments and Restore carry the numbers over
+	// to a later run.
+	NoDirectories bool
+
+	mu    sync.Mutex
+	flat  map[string]string // document URL to its file with NoDirectories
+	taken map[string]bool
+}
 
 func (m *FilePathMapper) Map(current string) string {
+	return m.mapURL(current, !m.NoHostDirectories)
+}
+
+// MapWithHost maps current like Map, but keeps its host directory despite
+// NoHostDirectories. It does not add directories with NoDirectories.
+func (m *FilePathMapper) MapWithHost(current string) string {
+	return m.mapURL(current, true)
+}
+
+func (m *FilePathMapper) mapURL(current string, hostDirectories bool) string {
 	u, err := url.Parse(current)
 	if err != nil {
 		return "default.html"
 	}
+	dirs, name := splitPath(u.Path)
+	if m.NoDirectories {
+		// Like the other layouts, the query and fragment are not part of the file.
+		return m.flatName(u.Scheme+"://"+strings.ToLower(u.Host)+u.Path, name)
+	}
 
-	pth := u.Path
-	if pth == "" || pth == "/" {
-		return "index.html"
+	dirs = dirs[min(max(m.CutDirs, 0), len(dirs)):]
+	if hostDirectories && u.Host != "" {
+		dirs = append([]string{hostDirectory(u)}, dirs...)
+		if m.ProtocolDirectories {
+			dirs = append([]string{u.Scheme}, dirs...)
+		}
 	}
+	return path.Join(append(dirs, name)...)
+}
 
-	if pth[0] == '/' {
-		pth = pth[1:]
+// splitPath returns the directories of a URL path and the file name. Paths
+// without an extension are directories with an index.html.
+func splitPath(urlPath string) ([]string, string) {
+	// Dot segments are resolved so that no file lands outside its directory.
+	var segments []string
+	for _, segment := range strings.Split(path.Clean("/"+urlPath), "/") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) == 0 || strings.HasSuffix(urlPath, "/") || path.Ext(segments[len(segments)-1]) == "" {
+		return segments, "index.html"
 	}
+	return segments[:len(segments)-1], segments[len(segments)-1]
+}
 
-	if strings.HasSuffix(pth, "/") {
-		pth = strings.TrimSuffix(pth, "/")
-		return path.Join(pth, "index.html")
+// hostDirectory returns the lower case host, with the port unless it is the
+// default one of the scheme.
+func hostDirectory(u *url.URL) string {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" || u.Scheme == "http" && port == "80" || u.Scheme == "https" && port == "443" {
+		return host
 	}
+	return net.JoinHostPort(host, port)
+}
 
-	if path.Ext(pth) != "" {
-		return pth
+// flatName returns the file of a document with NoDirectories, numbering
+// name when another document already took it.
+func (m *FilePathMapper) flatName(document, name string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if file, ok := m.flat[document]; ok {
+		return file
+	}
+	if m.flat == nil {
+		m.flat = map[string]string{}
+		m.taken = map[string]bool{}
+	}
+	file := name
+	for i := 1; m.taken[file]; i++ {
+		file = fmt.Sprintf("%s.%d", name, i)
 	}
+	m.flat[document] = file
+	m.taken[file] = true
+	return file
+}
+
+// Assignments returns the files given to documents with NoDirectories.
+func (m *FilePathMapper) Assignments() map[string]string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return maps.Clone(m.flat)
+}
 
-	return path.Join(pth, "index.html")
+// Restore gives documents the files of an earlier run before any URL is
+// mapped.
+func (m *FilePathMapper) Restore(assignments map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.flat == nil {
+		m.flat = map[string]string{}
+		m.taken = map[string]bool{}
+	}
+	for document, file := range assignments {
+		m.flat[document] = file
+		m.taken[file] = true
+	}
 }
@@ -0,0 +1,98 @@
+package robots
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+	"wget/downloader"
+)
+
+// Checker fetches robots.txt once per host through a Downloader and answers
+// whether URLs of that host may be crawled.
+type Checker struct {
+	Downloader downloader.Downloader
+	UserAgent  string
+
+	mu    sync.Mutex
+	hosts map[string]*hostRules
+}
+
+type hostRules struct {
+	once     sync.Once
+	group    *Group
+	sitemaps []string
+}
+
+func NewChecker(downloader downloader.Downloader, userAgent string) *Checker {
+	return &Checker{
+		Downloader: downloader,
+		UserAgent:  userAgent,
+		hosts:      map[string]*hostRules{},
+	}
+}
+
+func (c *Checker) Allowed(ctx context.Context, rawUrl string) bool {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Scheme != "http" && u.Scheme != "https" {
+		return true
+	}
+	return c.host(ctx, u).group.Allowed(u.RequestURI())
+}
+
+// CrawlDelay returns the Crawl-delay requested for the host of rawUrl.
+func (c *Checker) CrawlDelay(ctx context.Context, rawUrl string) time.Duration {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Scheme != "http" && u.Scheme != "https" {
+		return 0
+	}
+	return c.host(ctx, u).group.CrawlDelay
+}
+
+// Sitemaps returns the sitemaps listed in robots.txt of the host of rawUrl.
+func (c *Checker) Sitemaps(ctx context.Context, rawUrl string) []string {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Scheme != "http" && u.Scheme != "https" {
+		return nil
+	}
+	return c.host(ctx, u).sitemaps
+}
+
+func (c *Checker) host(ctx context.Context, u *url.URL) *hostRules {
+	origin := u.Scheme + "://" + u.Host
+	c.mu.Lock()
+	if c.hosts == nil {
+		c.hosts = map[string]*hostRules{}
+	}
+	host, ok := c.hosts[origin]
+	if !ok {
+		host = &hostRules{}
+		c.hosts[origin] = host
+	}
+	c.mu.Unlock()
+
+	host.once.Do(func() {
+		host.group, host.sitemaps = c.fetch(ctx, origin)
+	})
+	return host
+}
+
+// fetch follows RFC 9309: a missing robots.txt (4xx) allows everything, an
+// unreachable one (5xx or network error) disallows everything.
+func (c *Checker) fetch(ctx context.Context, origin string) (*Group, []string) {
+	response, err := c.Downloader.Download(ctx, origin+"/robots.txt")
+	if err != nil {
+		var downloadErr *downloader.DownloadError
+		if errors.As(err, &downloadErr) && downloadErr.StatusCode >= 400 && downloadErr.StatusCode < 500 {
+			return AllowAll, nil
+		}
+		return DisallowAll, nil
+	}
+	data, err := response.ReadAll(maxSize)
+	if err != nil {
+		return DisallowAll, nil
+	}
+	rules := Parse(data)
+	return rules.Agent(c.UserAgent), rules.Sitemaps
+}
@@ -0,0 +1,169 @@
+package robots
+
+import (
+	"bufio"
+	"bytes"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxSize is the amount of robots.txt that is parsed, as required by RFC 9309.
+const maxSize = 500 * 1024
+
+// Rules is a parsed robots.txt file.
+type Rules struct {
+	groups []*group
+	// Sitemaps lists the Sitemap lines, which apply to every user agent.
+	Sitemaps []string
+}
+
+type group struct {
+	agents     []string
+	rules      []rule
+	crawlDelay time.Duration
+}
+
+type rule struct {
+	allow   bool
+	pattern string
+}
+
+// Group holds the rules that apply to one user agent.
+type Group struct {
+	rules      []rule
+	CrawlDelay time.Duration
+}
+
+var (
+	// AllowAll is used when robots.txt is missing.
+	AllowAll = &Group{}
+	// DisallowAll is used when robots.txt is unreachable.
+	DisallowAll = &Group{rules: []rule{{allow: false, pattern: "/"}}}
+)
+
+func Parse(data []byte) *Rules {
+	if len(data) > maxSize {
+		data = data[:maxSize]
+	}
+
+	rules := &Rules{}
+	var current *group
+	agentsDone := true
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			// Consecutive user-agent lines share one group.
+			if agentsDone {
+				current = &group{}
+				rules.groups = append(rules.groups, current)
+				agentsDone = false
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+		case "allow", "disallow":
+			agentsDone = true
+			if current != nil && value != "" {
+				current.rules = append(current.rules, rule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			agentsDone = true
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil && current != nil && seconds >= 0 {
+				current.crawlDelay = time.Duration(seconds * float64(time.Second))
+			}
+		case "sitemap":
+			if value != "" {
+				rules.Sitemaps = append(rules.Sitemaps, value)
+			}
+		}
+	}
+	return rules
+}
+
+// Agent merges the groups that name the product token of userAgent, or the
+// "*" groups if none does.
+func (r *Rules) Agent(userAgent string) *Group {
+	token := strings.ToLower(userAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+
+	matched := &Group{}
+	found := false
+	for _, g := range r.groups {
+		for _, agent := range g.agents {
+			if agent != "*" && agent != "" && strings.HasPrefix(token, agent) {
+				matched.rules = append(matched.rules, g.rules...)
+				matched.CrawlDelay = max(matched.CrawlDelay, g.crawlDelay)
+				found = true
+				break
+			}
+		}
+	}
+	if found {
+		return matched
+	}
+
+	for _, g := range r.groups {
+		if slices.Contains(g.agents, "*") {
+			matched.rules = append(matched.rules, g.rules...)
+			matched.CrawlDelay = max(matched.CrawlDelay, g.crawlDelay)
+		}
+	}
+	return matched
+}
+
+// Allowed reports whether path, including the query, may be fetched. The
+// longest matching rule wins, and Allow wins a tie.
+func (g *Group) Allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+
+	allowed, longest := true, -1
+	for _, r := range g.rules {
+		if !match(r.pattern, path) {
+			continue
+		}
+		if length := len(r.pattern); length > longest || length == longest && r.allow {
+			allowed, longest = r.allow, length
+		}
+	}
+	return allowed
+}
+
+// match matches path against a pattern where "*" stands for any sequence of
+// characters and a trailing "$" anchors the end of the path.
+func match(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	for i, part := range parts[1:] {
+		if i == len(parts)-2 && anchored {
+			return len(path)-pos >= len(part) && strings.HasSuffix(path, part)
+		}
+		index := strings.Index(path[pos:], part)
+		if index < 0 {
+			return false
+		}
+		pos += index + len(part)
+	}
+	return !anchored || pos == len(path)
+}
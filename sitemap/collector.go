@@ -0,0 +1,117 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"wget/downloader"
+)
+
+const defaultMaxSitemaps = 1000
+
+// SitemapLister returns the sitemaps a site announces, e.g. in robots.txt.
+type SitemapLister interface {
+	Sitemaps(ctx context.Context, rawUrl string) []string
+}
+
+// Collector gathers the pages listed in the sitemaps of a site, following
+// sitemap indexes.
+type Collector struct {
+	Downloader  downloader.Downloader
+	Robots      SitemapLister // optional, /sitemap.xml is tried when it lists nothing
+	MaxSitemaps int           // 0 means defaultMaxSitemaps
+}
+
+func NewCollector(downloader downloader.Downloader, robots SitemapLister) *Collector {
+	return &Collector{
+		Downloader: downloader,
+		Robots:     robots,
+	}
+}
+
+// Pages returns the pages listed for the site of seed. Sitemaps that fail are
+// reported in the error while the pages of the others are still returned.
+func (c *Collector) Pages(ctx context.Context, seed string) ([]Page, error) {
+	u, err := url.Parse(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	var queue []string
+	if c.Robots != nil {
+		queue = c.Robots.Sitemaps(ctx, seed)
+	}
+	// A guessed location is optional, so its failure is not an error.
+	guessed := ""
+	if len(queue) == 0 {
+		guessed = u.Scheme + "://" + u.Host + "/sitemap.xml"
+		queue = []string{guessed}
+	}
+
+	var pages []Page
+	var errs []error
+	visited := map[string]bool{}
+	for len(queue) > 0 && len(visited) < c.maxSitemaps() && ctx.Err() == nil {
+		loc := queue[0]
+		queue = queue[1:]
+		if visited[loc] {
+			continue
+		}
+		visited[loc] = true
+
+		sitemap, err := c.fetch(ctx, loc)
+		if err != nil {
+			if loc != guessed {
+				errs = append(errs, fmt.Errorf("sitemap %s: %w", loc, err))
+			}
+			continue
+		}
+		pages = append(pages, sitemap.Pages...)
+		for _, s := range sitemap.Sitemaps {
+			queue = append(queue, s.URL)
+		}
+	}
+	return pages, errors.Join(errs...)
+}
+
+func (c *Collector) fetch(ctx context.Context, loc string) (*Sitemap, error) {
+	response, err := c.Downloader.Download(ctx, loc)
+	if err != nil {
+		return nil, err
+	}
+	// One byte more than allowed lets Parse reject oversized sitemaps.
+	data, err := response.ReadAll(maxSize + 1)
+	if err != nil {
+		return nil, err
+	}
+	sitemap, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	base, err := url.Parse(response.URL)
+	if err != nil {
+		return sitemap, nil
+	}
+	resolve(base, sitemap.Pages)
+	resolve(base, sitemap.Sitemaps)
+	return sitemap, nil
+}
+
+// resolve makes relative locations absolute, which the protocol forbids but
+// some generators produce anyway.
+func resolve(base *url.URL, pages []Page) {
+	for i, page := range pages {
+		if ref, err := url.Parse(page.URL); err == nil {
+			pages[i].URL = base.ResolveReference(ref).String()
+		}
+	}
+}
+
+func (c *Collector) maxSitemaps() int {
+	if c.MaxSitemaps < 1 {
+		return defaultMaxSitemaps
+	}
+	return c.MaxSitemaps
+}
@@ -0,0 +1,135 @@
+package sitemap
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxSize is the largest uncompressed sitemap allowed by the sitemaps protocol.
+const maxSize = 50 * 1024 * 1024
+
+// Page is an entry of a sitemap. LastMod is zero when it is absent or invalid.
+type Page struct {
+	URL     string
+	LastMod time.Time
+}
+
+// Sitemap is a parsed urlset, sitemap index or plain text sitemap.
+type Sitemap struct {
+	Pages    []Page
+	Sitemaps []Page // entries of a sitemap index
+}
+
+type document struct {
+	XMLName  xml.Name
+	URLs     []entry `xml:"url"`
+	Sitemaps []entry `xml:"sitemap"`
+}
+
+type entry struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// Parse parses a sitemap, decompressing it first if it is gzipped.
+func Parse(data []byte) (*Sitemap, error) {
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		var err error
+		if data, err = gunzip(data); err != nil {
+			return nil, err
+		}
+	}
+	if len(data) > maxSize {
+		return nil, fmt.Errorf("sitemap exceeds %d bytes", maxSize)
+	}
+
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		return parseText(data), nil
+	}
+
+	var doc document
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.XMLName.Local != "urlset" && doc.XMLName.Local != "sitemapindex" {
+		return nil, fmt.Errorf("unexpected sitemap root element <%s>", doc.XMLName.Local)
+	}
+
+	sitemap := &Sitemap{}
+	for _, e := range doc.URLs {
+		if page, ok := e.page(); ok {
+			sitemap.Pages = append(sitemap.Pages, page)
+		}
+	}
+	for _, e := range doc.Sitemaps {
+		if page, ok := e.page(); ok {
+			sitemap.Sitemaps = append(sitemap.Sitemaps, page)
+		}
+	}
+	return sitemap, nil
+}
+
+func (e entry) page() (Page, bool) {
+	loc := strings.TrimSpace(e.Loc)
+	if loc == "" {
+		return Page{}, false
+	}
+	lastMod, _ := ParseLastMod(e.LastMod)
+	return Page{URL: loc, LastMod: lastMod}, true
+}
+
+// parseText parses a sitemap that lists one URL per line.
+func parseText(data []byte) *Sitemap {
+	sitemap := &Sitemap{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			sitemap.Pages = append(sitemap.Pages, Page{URL: line})
+		}
+	}
+	return sitemap
+}
+
+func gunzip(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err = io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSize {
+		return nil, errors.New("decompressed sitemap is too large")
+	}
+	return data, nil
+}
+
+// lastModLayouts are the W3C Datetime formats used by lastmod.
+var lastModLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02",
+	"2006-01",
+	"2006",
+}
+
+// ParseLastMod parses a lastmod value in W3C Datetime format.
+func ParseLastMod(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range lastModLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid W3C datetime %q", value)
+}
@@ -1,25 +1,103 @@
 package storage
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 )
 
+// FileSaver stores documents. Save streams data to path, which is replaced
+// only once all of data has been written. What was written before a failure
+// is kept as a partial file that Resume can continue.
 type FileSaver interface {
-	Save(path string, data []byte) error
+	Save(path string, data io.Reader) error
+	Load(path string) ([]byte, error)
+	Exists(path string) bool
+	SetModTime(path string, modTime time.Time) error
+	// Partial returns the size of the partial file of path, 0 if there is none.
+	Partial(path string) int64
+	// Resume is Save for data that starts at offset of the partial file.
+	Resume(path string, offset int64, data io.Reader) error
 }
 
+// partSuffix marks a file that is still being written.
+const partSuffix = ".part"
+
 type OsFileSaver struct {
 	OutputDir string
+
+	locks sync.Map // path to *sync.Mutex, serializes writes to one partial file
+}
+
+func (s *OsFileSaver) Save(path string, data io.Reader) error {
+	return s.Resume(path, 0, data)
 }
 
-func (s *OsFileSaver) Save(path string, data []byte) error {
+func (s *OsFileSaver) Resume(path string, offset int64, data io.Reader) error {
+	lock, _ := s.locks.LoadOrStore(path, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
 	fullPath := filepath.Join(s.OutputDir, path)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return err
+	}
+
+	// Write to a partial file first so that an interrupted save never
+	// leaves a truncated file behind.
+	part, err := os.OpenFile(fullPath+partSuffix, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	err = writeAt(part, offset, data)
+	if closeErr := part.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(part.Name(), fullPath)
+	}
+	return err
+}
 
-	dir := filepath.Dir(fullPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+// writeAt replaces the content of file from offset on with data.
+func writeAt(file *os.File, offset int64, data io.Reader) error {
+	info, err := file.Stat()
+	if err != nil {
 		return err
 	}
+	if info.Size() < offset {
+		return fmt.Errorf("%s has %d bytes, cannot resume at %d", file.Name(), info.Size(), offset)
+	}
+	if err := file.Truncate(offset); err != nil {
+		return err
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	_, err = io.Copy(file, data)
+	return err
+}
+
+func (s *OsFileSaver) Partial(path string) int64 {
+	info, err := os.Stat(filepath.Join(s.OutputDir, path) + partSuffix)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func (s *OsFileSaver) Load(path string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.OutputDir, path))
+}
+
+func (s *OsFileSaver) Exists(path string) bool {
+	_, err := os.Stat(filepath.Join(s.OutputDir, path))
+	return err == nil
+}
 
-	return os.WriteFile(fullPath, data, 0644)
+func (s *OsFileSaver) SetModTime(path string, modTime time.Time) error {
+	return os.Chtimes(filepath.Join(s.OutputDir, path), modTime, modTime)
 }
@@ -0,0 +1,250 @@
+package tests
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"wget/downloader"
+)
+
+func hostOf(server *httptest.Server) string {
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func downloadText(t *testing.T, d downloader.Downloader, url string) (string, error) {
+	t.Helper()
+	response, err := d.Download(context.Background(), url)
+	if err != nil {
+		return "", err
+	}
+	body, err := response.ReadAll(1 << 20)
+	return string(body), err
+}
+
+func newAuthDownloader(t *testing.T, settings downloader.HTTPDownloaderSettings) *downloader.HTTPDownloader {
+	t.Helper()
+	d, err := downloader.NewHTTPDownloader(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestAuth_Basic_AnswersChallengeThenSendsPreemptively(t *testing.T) {
+	var challenged atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "alice" || password != "secret" {
+			challenged.Add(1)
+			w.Header().Set("WWW-Authenticate", `Basic realm="wiki"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("page " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	d := newAuthDownloader(t, downloader.HTTPDownloaderSettings{
+		Credentials: map[string]downloader.Credentials{hostOf(server): {Username: "alice", Password: "secret"}},
+	})
+
+	for _, path := range []string{"/a", "/b"} {
+		body, err := downloadText(t, d, server.URL+path)
+		if err != nil || body != "page "+path {
+			t.Fatalf("Expected page %s, got %q (%v)", path, body, err)
+		}
+	}
+	// после первого вызова учётные данные отправляются сразу
+	if challenged.Load() != 1 {
+		t.Errorf("Expected 1 challenge, got %d", challenged.Load())
+	}
+}
+
+func TestAuth_Basic_WrongPasswordFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="wiki"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	d := newAuthDownloader(t, downloader.HTTPDownloaderSettings{
+		Credentials: map[string]downloader.Credentials{hostOf(server): {Username: "alice", Password: "wrong"}},
+	})
+
+	_, err := downloadText(t, d, server.URL+"/")
+	var downloadErr *downloader.DownloadError
+	if !errors.As(err, &downloadErr) || downloadErr.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected a 401 DownloadError, got %v", err)
+	}
+}
+
+func digestParams(header string) map[string]string {
+	params := map[string]string{}
+	for _, field := range strings.Split(strings.TrimPrefix(header, "Digest "), ", ") {
+		key, value, _ := strings.Cut(field, "=")
+		params[key] = strings.Trim(value, `"`)
+	}
+	return params
+}
+
+// digestServer проверяет ответ Digest с qop=auth по RFC 7616.
+func digestServer(algorithm string, newHash func() hash.Hash, nonces *atomic.Int32) *httptest.Server {
+	h := func(parts ...string) string {
+		sum := newHash()
+		sum.Write([]byte(strings.Join(parts, ":")))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := digestParams(r.Header.Get("Authorization"))
+		ha1 := h("bob", "intranet", "pa55")
+		ha2 := h(r.Method, r.URL.RequestURI())
+		expected := h(ha1, "n0nce", params["nc"], params["cnonce"], "auth", ha2)
+		if params["response"] != expected || params["opaque"] != "op" || params["uri"] != r.URL.RequestURI() {
+			nonces.Add(1)
+			w.Header().Add("WWW-Authenticate", `Basic realm="intranet"`)
+			w.Header().Add("WWW-Authenticate",
+				`Digest realm="intranet", qop="auth,auth-int", nonce="n0nce", opaque="op", algorithm=`+algorithm)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("ok " + params["nc"]))
+	}))
+}
+
+func TestAuth_Digest_SupportsMD5AndSHA256(t *testing.T) {
+	for algorithm, newHash := range map[string]func() hash.Hash{"MD5": md5.New, "SHA-256": sha256.New} {
+		t.Run(algorithm, func(t *testing.T) {
+			var challenges atomic.Int32
+			server := digestServer(algorithm, newHash, &challenges)
+			defer server.Close()
+			d := newAuthDownloader(t, downloader.HTTPDownloaderSettings{
+				Credentials: map[string]downloader.Credentials{hostOf(server): {Username: "bob", Password: "pa55"}},
+			})
+
+			first, err := downloadText(t, d, server.URL+"/wiki?page=1")
+			if err != nil {
+				t.Fatalf("Download returned an error: %v", err)
+			}
+			second, err := downloadText(t, d, server.URL+"/wiki?page=2")
+			if err != nil {
+				t.Fatalf("Download returned an error: %v", err)
+			}
+
+			// второй запрос использует тот же nonce со следующим счётчиком
+			if first != "ok 00000001" || second != "ok 00000002" || challenges.Load() != 1 {
+				t.Errorf("Expected nc 1 and 2 after one challenge, got %q, %q after %d", first, second, challenges.Load())
+			}
+		})
+	}
+}
+
+func TestAuth_Bearer_SentOnlyToItsHost(t *testing.T) {
+	var leaked atomic.Value
+	leaked.Store("")
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		leaked.Store(r.Header.Get("Authorization"))
+		_, _ = w.Write([]byte("other"))
+	}))
+	defer other.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t0ken" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.Redirect(w, r, other.URL+"/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	d := newAuthDownloader(t, downloader.HTTPDownloaderSettings{
+		BearerTokens: map[string]string{hostOf(server): "t0ken"},
+	})
+
+	body, err := downloadText(t, d, server.URL+"/")
+	if err != nil || body != "other" {
+		t.Fatalf("Expected the redirect to be followed, got %q (%v)", body, err)
+	}
+	if leaked.Load() != "" {
+		t.Errorf("Expected no Authorization for the other host, got %q", leaked.Load())
+	}
+}
+
+func TestAuth_Credentials_NotSentToOtherHosts(t *testing.T) {
+	var leaked atomic.Bool
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" {
+			leaked.Store(true)
+		}
+		w.Header().Set("WWW-Authenticate", `Basic realm="other"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer other.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, ok := r.BasicAuth(); !ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="wiki"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.Redirect(w, r, other.URL+"/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	d := newAuthDownloader(t, downloader.HTTPDownloaderSettings{
+		Credentials: map[string]downloader.Credentials{hostOf(server): {Username: "alice", Password: "secret"}},
+	})
+
+	_, err := downloadText(t, d, server.URL+"/")
+	if err == nil {
+		t.Fatal("Expected the other host to refuse the request")
+	}
+	if leaked.Load() {
+		t.Error("Expected no credentials for the other host")
+	}
+}
+
+func TestParseNetrc_ReadsMachineEntries(t *testing.T) {
+	credentials, err := downloader.ParseNetrc(strings.NewReader(`
+# внутренние вики
+machine wiki.example.com login alice password secret
+machine Docs.Example.com
+	login bob
+	password pa55
+	account ignored
+
+macdef init
+machine fake login x password y
+
+default login anonymous password guest
+`))
+
+	if err != nil {
+		t.Fatalf("ParseNetrc returned an error: %v", err)
+	}
+	expected := map[string]downloader.Credentials{
+		"wiki.example.com": {Username: "alice", Password: "secret"},
+		"docs.example.com": {Username: "bob", Password: "pa55"},
+	}
+	if len(credentials) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, credentials)
+	}
+	for host, want := range expected {
+		if credentials[host] != want {
+			t.Errorf("Expected %v for %s, got %v", want, host, credentials[host])
+		}
+	}
+}
+
+func TestParseNetrc_RejectsMissingValue(t *testing.T) {
+	_, err := downloader.ParseNetrc(strings.NewReader("machine example.com login"))
+
+	if err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected an error for line 1, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"wget/downloader"
+)
+
+func TestParseRate(t *testing.T) {
+	cases := map[string]int64{
+		"0":     0,
+		"1000":  1000,
+		"500k":  500 * 1024,
+		"2M":    2 * 1024 * 1024,
+		"1.5m":  1536 * 1024,
+		"1G":    1 << 30,
+		" 10K ": 10 * 1024,
+	}
+	for value, expected := range cases {
+		got, err := downloader.ParseRate(value)
+		if err != nil || got != expected {
+			t.Errorf("ParseRate(%q) = %d, %v, expected %d", value, got, err, expected)
+		}
+	}
+
+	for _, value := range []string{"", "k", "fast", "-1k", "10x", "NaN", "Inf", "+Inf", "-Inf", "1e30g"} {
+		if _, err := downloader.ParseRate(value); err == nil {
+			t.Errorf("Expected an error for %q", value)
+		}
+	}
+}
+
+func downloadAll(t *testing.T, d downloader.Downloader, urls ...string) time.Duration {
+	t.Helper()
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, u := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := d.Download(context.Background(), u)
+			if err != nil {
+				t.Errorf("Download returned an error: %v", err)
+				return
+			}
+			if body := readBody(t, response); len(body) != 4000 {
+				t.Errorf("Expected 4000 bytes, got %d", len(body))
+			}
+		}()
+	}
+	wg.Wait()
+	return time.Since(start)
+}
+
+func TestThrottledDownloader_LimitsRateWhileReading(t *testing.T) {
+	body := []byte(strings.Repeat("x", 4000))
+	mock := NewMockDownloader(map[string][]byte{"https://a.com": body, "https://b.com": body}, nil)
+
+	// 4000 байт при 20000 байт/с, первые 2500 байт (burst) без ожидания
+	perConnection := downloader.NewThrottledDownloader(mock, downloader.BandwidthSettings{ConnectionLimitRate: 20000})
+	if elapsed := downloadAll(t, perConnection, "https://a.com", "https://b.com"); elapsed < 60*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Expected about 75ms with per-connection limits, got %v", elapsed)
+	}
+
+	// Общий лимит делится между соединениями: 8000 байт при 20000 байт/с
+	total := downloader.NewThrottledDownloader(mock, downloader.BandwidthSettings{LimitRate: 20000})
+	if elapsed := downloadAll(t, total, "https://a.com", "https://b.com"); elapsed < 250*time.Millisecond {
+		t.Errorf("Expected at least 275ms with a total limit, got %v", elapsed)
+	}
+}
+
+func TestThrottledDownloader_StopsWaitingOnCancel(t *testing.T) {
+	mock := NewMockDownloader(map[string][]byte{"https://a.com": []byte(strings.Repeat("x", 100000))}, nil)
+	d := downloader.NewThrottledDownloader(mock, downloader.BandwidthSettings{LimitRate: 1000})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	response, err := d.Download(ctx, "https://a.com")
+	if err != nil {
+		t.Fatalf("Download returned an error: %v", err)
+	}
+	defer response.Body.Close()
+
+	buffer := make([]byte, 100000)
+	for err == nil {
+		_, err = response.Body.Read(buffer)
+	}
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
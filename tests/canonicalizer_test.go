@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"testing"
+	"wget/webcrawler"
+)
+
+func TestCanonicalizer_Canonicalize_DefaultRules(t *testing.T) {
+	c := webcrawler.Canonicalizer{}
+
+	cases := map[string]string{
+		"https://example.com":               "https://example.com/",
+		"HTTPS://EXAMPLE.com/Page":          "https://example.com/Page",
+		"https://example.com:443/page":      "https://example.com/page",
+		"http://example.com:80/page":        "http://example.com/page",
+		"http://example.com:8080/page":      "http://example.com:8080/page",
+		"https://example.com/page#section":  "https://example.com/page",
+		"https://example.com/%7euser/a%2fb": "https://example.com/~user/a%2Fb",
+		"https://example.com/caf%c3%a9":     "https://example.com/caf%C3%A9",
+		"https://example.com/a?y=2&x=1":     "https://example.com/a?y=2&x=1",
+		"https://example.com/a?":            "https://example.com/a",
+	}
+	for input, expected := range cases {
+		if got := c.Canonicalize(input); got != expected {
+			t.Errorf("Canonicalize(%s) = %s, expected %s", input, got, expected)
+		}
+	}
+}
+
+func TestCanonicalizer_Canonicalize_OptionalRules(t *testing.T) {
+	c := webcrawler.Canonicalizer{
+		KeepFragment: true,
+		SortQuery:    true,
+		StripParams:  webcrawler.DefaultTrackingParams,
+	}
+
+	cases := map[string]string{
+		"https://example.com/a?y=2&x=1":                        "https://example.com/a?x=1&y=2",
+		"https://example.com/a?utm_source=x&id=5&utm_medium=y": "https://example.com/a?id=5",
+		"https://example.com/a?fbclid=abc":                     "https://example.com/a",
+		"https://example.com/a#top":                            "https://example.com/a#top",
+	}
+	for input, expected := range cases {
+		if got := c.Canonicalize(input); got != expected {
+			t.Errorf("Canonicalize(%s) = %s, expected %s", input, got, expected)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"wget/cookies"
+	"wget/downloader"
+)
+
+func mustParseURL(t *testing.T, rawUrl string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func cookieNames(list []*http.Cookie) string {
+	names := make([]string, len(list))
+	for i, cookie := range list {
+		names[i] = cookie.Name + "=" + cookie.Value
+	}
+	return strings.Join(names, "; ")
+}
+
+func assertCookies(t *testing.T, jar *cookies.Jar, rawUrl, expected string) {
+	t.Helper()
+	if got := cookieNames(jar.Cookies(mustParseURL(t, rawUrl))); got != expected {
+		t.Errorf("Cookies(%s) = %q, expected %q", rawUrl, got, expected)
+	}
+}
+
+func TestJar_Cookies_MatchesDomainAndPath(t *testing.T) {
+	jar := cookies.NewJar()
+	jar.SetCookies(mustParseURL(t, "https://www.example.com/docs/page"), []*http.Cookie{
+		{Name: "host", Value: "1"}, // host-only, путь по умолчанию /docs
+		{Name: "domain", Value: "2", Domain: ".example.com", Path: "/"},
+		{Name: "api", Value: "3", Path: "/api"},
+	})
+
+	assertCookies(t, jar, "https://www.example.com/docs/other", "host=1; domain=2")
+	assertCookies(t, jar, "https://www.example.com/api/v1", "api=3; domain=2")
+	assertCookies(t, jar, "https://www.example.com/apis", "domain=2")
+	assertCookies(t, jar, "https://cdn.example.com/docs/x", "domain=2")
+	assertCookies(t, jar, "https://example.org/docs/x", "")
+}
+
+func TestJar_SetCookies_RejectsForeignAndPublicSuffixDomains(t *testing.T) {
+	jar := cookies.NewJar()
+	jar.SetCookies(mustParseURL(t, "http://www.example.com/"), []*http.Cookie{
+		{Name: "foreign", Value: "1", Domain: "example.org"},
+		{Name: "suffix", Value: "2", Domain: "com"},
+		{Name: "sibling", Value: "3", Domain: "other.example.com"},
+	})
+
+	assertCookies(t, jar, "http://www.example.com/", "")
+	assertCookies(t, jar, "http://example.org/", "")
+	assertCookies(t, jar, "http://other.com/", "")
+}
+
+func TestJar_SetCookies_PublicSuffixHostGetsHostOnlyCookie(t *testing.T) {
+	jar := cookies.NewJar()
+	jar.SetCookies(mustParseURL(t, "http://github.io/"), []*http.Cookie{
+		{Name: "id", Value: "1", Domain: "github.io"},
+	})
+
+	assertCookies(t, jar, "http://github.io/", "id=1")
+	assertCookies(t, jar, "http://user.github.io/", "")
+}
+
+func TestJar_Cookies_SecureOnlyOverHTTPS(t *testing.T) {
+	jar := cookies.NewJar()
+	jar.SetCookies(mustParseURL(t, "https://example.com/"), []*http.Cookie{
+		{Name: "secure", Value: "1", Secure: true},
+		{Name: "plain", Value: "2"},
+	})
+
+	assertCookies(t, jar, "https://example.com/", "secure=1; plain=2")
+	assertCookies(t, jar, "http://example.com/", "plain=2")
+}
+
+func TestJar_SetCookies_AppliesExpiry(t *testing.T) {
+	jar := cookies.NewJar()
+	u := mustParseURL(t, "http://example.com/")
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "old", Value: "1", Expires: time.Now().Add(-time.Hour)},
+		{Name: "fresh", Value: "2", Expires: time.Now().Add(time.Hour)},
+		{Name: "gone", Value: "3"},
+		// Max-Age важнее Expires
+		{Name: "maxage", Value: "4", MaxAge: 60, Expires: time.Now().Add(-time.Hour)},
+	})
+	jar.SetCookies(u, []*http.Cookie{{Name: "gone", MaxAge: -1}})
+
+	assertCookies(t, jar, "http://example.com/", "fresh=2; maxage=4")
+}
+
+func TestJar_SetCookies_ReplacesCookieKeepingOrder(t *testing.T) {
+	jar := cookies.NewJar()
+	u := mustParseURL(t, "http://example.com/")
+	jar.SetCookies(u, []*http.Cookie{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}})
+	jar.SetCookies(u, []*http.Cookie{{Name: "a", Value: "new"}})
+
+	assertCookies(t, jar, "http://example.com/", "a=new; b=2")
+}
+
+func TestJar_SaveAndLoad_RoundTripsNetscapeFormat(t *testing.T) {
+	expires := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	jar := cookies.NewJar()
+	jar.SetCookies(mustParseURL(t, "https://www.example.com/"), []*http.Cookie{
+		{Name: "id", Value: "42", Domain: "example.com", Path: "/", Secure: true, HttpOnly: true, Expires: expires},
+		{Name: "lang", Value: "ru", Path: "/docs", Expires: expires},
+		{Name: "session", Value: "s"},
+	})
+
+	var withoutSession strings.Builder
+	if err := jar.Save(&withoutSession, false); err != nil {
+		t.Fatal(err)
+	}
+	expected := "# Netscape HTTP Cookie File\n" +
+		"#HttpOnly_.example.com\tTRUE\t/\tTRUE\t" + strconv.FormatInt(expires.Unix(), 10) + "\tid\t42\n" +
+		"www.example.com\tFALSE\t/docs\tFALSE\t" + strconv.FormatInt(expires.Unix(), 10) + "\tlang\tru\n"
+	if withoutSession.String() != expected {
+		t.Errorf("Expected cookies.txt\n%s\ngot\n%s", expected, withoutSession.String())
+	}
+
+	var withSession strings.Builder
+	if err := jar.Save(&withSession, true); err != nil {
+		t.Fatal(err)
+	}
+	loaded := cookies.NewJar()
+	if err := loaded.Load(strings.NewReader(withSession.String())); err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	assertCookies(t, loaded, "https://www.example.com/docs/a", "lang=ru; id=42; session=s")
+	assertCookies(t, loaded, "https://cdn.example.com/docs/a", "id=42")
+	assertCookies(t, loaded, "http://www.example.com/", "session=s")
+}
+
+func TestJar_Load_SkipsCommentsAndExpiredCookies(t *testing.T) {
+	jar := cookies.NewJar()
+	err := jar.Load(strings.NewReader("# Netscape HTTP Cookie File\r\n" +
+		"\n" +
+		"# example.com\tFALSE\t/\tFALSE\t0\tcomment\t1\n" +
+		"example.com\tFALSE\t/\tFALSE\t1\texpired\t2\n" +
+		"example.com\tFALSE\t/\tFALSE\t0\tsession\t3\r\n"))
+
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	assertCookies(t, jar, "http://example.com/", "session=3")
+}
+
+func TestJar_Load_RejectsMalformedLine(t *testing.T) {
+	jar := cookies.NewJar()
+	err := jar.Load(strings.NewReader("example.com\tFALSE\t/\tFALSE\tsoon\tname\tvalue\n"))
+
+	if err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("Expected an error for line 1, got %v", err)
+	}
+}
+
+func TestHTTPDownloader_Download_KeepsCookiesAcrossRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+			http.Redirect(w, r, "/private", http.StatusFound)
+		case "/private":
+			cookie, err := r.Cookie("session")
+			if err != nil || cookie.Value != "abc" {
+				http.Error(w, "no session", http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte("secret"))
+		}
+	}))
+	defer server.Close()
+
+	jar := cookies.NewJar()
+	d, err := downloader.NewHTTPDownloader(downloader.HTTPDownloaderSettings{Jar: jar})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := d.Download(context.Background(), server.URL+"/login")
+	if err != nil {
+		t.Fatalf("Download returned an error: %v", err)
+	}
+	body, err := response.ReadAll(1024)
+	if err != nil || string(body) != "secret" {
+		t.Errorf("Expected secret, got %q (%v)", body, err)
+	}
+	assertCookies(t, jar, server.URL+"/", "session=abc")
+}
@@ -2,8 +2,17 @@ package tests
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
+	"time"
+	"wget/downloader"
+	"wget/parser"
+	"wget/pathmapper"
+	"wget/robots"
+	"wget/sitemap"
 	"wget/webcrawler"
 )
 
@@ -26,7 +35,7 @@ func TestWebCrawler_Mirror_DownloadsAndSaves(t *testing.T) {
 
 	crawler := webcrawler.NewWebCrawler(
 		mockDownloader,
-		mockParser,
+		NewMockRegistry(mockParser),
 		mockPathMapper,
 		mockSaver,
 		settings,
@@ -77,7 +86,7 @@ func TestWebCrawler_Mirror_DownloadError(t *testing.T) {
 
 	crawler := webcrawler.NewWebCrawler(
 		mockDownloader,
-		mockParser,
+		NewMockRegistry(mockParser),
 		mockPathMapper,
 		mockSaver,
 		settings,
@@ -134,7 +143,7 @@ func TestWebCrawler_Mirror_DownloadsAbsoluteResources(t *testing.T) {
 
 	crawler := webcrawler.NewWebCrawler(
 		mockDownloader,
-		mockParser,
+		NewMockRegistry(mockParser),
 		mockPathMapper,
 		mockSaver,
 		settings,
@@ -195,7 +204,7 @@ func TestWebCrawler_Mirror_NormalizesRelativeResourceURLs(t *testing.T) {
 
 	crawler := webcrawler.NewWebCrawler(
 		mockDownloader,
-		mockParser,
+		NewMockRegistry(mockParser),
 		mockPathMapper,
 		mockSaver,
 		settings,
@@ -268,7 +277,7 @@ func TestWebCrawler_Mirror_RecursesLinksUnlimited(t *testing.T) {
 
 	crawler := webcrawler.NewWebCrawler(
 		mockDownloader,
-		mockParser,
+		NewMockRegistry(mockParser),
 		mockPathMapper,
 		mockSaver,
 		settings,
@@ -347,7 +356,7 @@ func TestWebCrawler_Mirror_RespectsMaxDepth(t *testing.T) {
 
 	crawler := webcrawler.NewWebCrawler(
 		mockDownloader,
-		mockParser,
+		NewMockRegistry(mockParser),
 		mockPathMapper,
 		mockSaver,
 		settings,
@@ -427,7 +436,7 @@ func TestWebCrawler_Mirror_AvoidsDuplicates(t *testing.T) {
 
 	crawler := webcrawler.NewWebCrawler(
 		mockDownloader,
-		mockParser,
+		NewMockRegistry(mockParser),
 		mockPathMapper,
 		mockSaver,
 		settings,
@@ -516,7 +525,7 @@ func TestWebCrawler_Mirror_RecoversFromLinkError(t *testing.T) {
 
 	crawler := webcrawler.NewWebCrawler(
 		mockDownloaderWithErrors,
-		mockParser,
+		NewMockRegistry(mockParser),
 		mockPathMapper,
 		mockSaver,
 		settings,
@@ -591,7 +600,7 @@ func TestWebCrawler_Mirror_IgnoresExternalLinks(t *testing.T) {
 
 	crawler := webcrawler.NewWebCrawler(
 		mockDownloader,
-		mockParser,
+		NewMockRegistry(mockParser),
 		mockPathMapper,
 		mockSaver,
 		settings,
@@ -639,84 +648,829 @@ func TestWebCrawler_Mirror_IgnoresExternalLinks(t *testing.T) {
 	}
 }
 
-// not implemented
-//func TestWebCrawler_Mirror_ReplacesAbsoluteResourceURLsInHTML(t *testing.T) {
-//	originalHTML := `<html>
-//<head>
-//    <link rel="stylesheet" href="https://example.com/style.css">
-//    <link rel="icon" href="https://example.com/favicon.ico">
-//</head>
-//<body>
-//    <img src="https://example.com/logo.png" alt="Logo">
-//</body>
-//</html>`
-//
-//	// Ожидаемый HTML после замены URL на локальные
-//	expectedHTML := `<html>
-//<head>
-//    <link rel="stylesheet" href="style.css">
-//    <link rel="icon" href="favicon.ico">
-//</head>
-//<body>
-//    <img src="logo.png" alt="Logo">
-//</body>
-//</html>`
-//
-//	// Подготовка
-//	mockDownloader := NewMockDownloader(map[string][]byte{
-//		"https://example.com":             []byte(originalHTML),
-//		"https://example.com/style.css":   []byte("body { color: red; }"),
-//		"https://example.com/favicon.ico": []byte("ico data"),
-//		"https://example.com/logo.png":    []byte("png data"),
-//	}, nil)
-//
-//	mockParser := NewMockHTMLParser([]string{"/style.css", "/favicon.ico", "/logo.png"}, []string{}, nil)
-//	mockPathMapper := NewMockPathMapper(map[string]string{
-//		"https://example.com":             "index.html",
-//		"https://example.com/style.css":   "style.css",
-//		"https://example.com/favicon.ico": "favicon.ico",
-//		"https://example.com/logo.png":    "logo.png",
-//	})
-//	mockSaver := NewMockFileSaver(nil)
-//
-//	settings := webcrawler.WebCrawlerSettings{
-//		MaxDepth:   1,
-//		MaxWorkers: 1,
-//	}
-//
-//	crawler := webcrawler.NewWebCrawler(
-//		mockDownloader,
-//		mockParser,
-//		mockPathMapper,
-//		mockSaver,
-//		settings,
-//	)
-//
-//	// Вызов
-//	result, err := crawler.Mirror(context.Background(), "https://example.com")
-//
-//	// Проверки
-//	if err != nil {
-//		t.Fatalf("Mirror returned an error: %v", err)
-//	}
-//
-//	// Проверяем, что файлы сохранены
-//	saved := mockSaver.GetSaved()
-//	if _, ok := saved["index.html"]; !ok {
-//		t.Fatalf("Expected file 'index.html' to be saved")
-//	}
-//
-//	// Проверяем, что в index.html были заменены URL
-//	savedHTML := string(saved["index.html"])
-//	if savedHTML != expectedHTML {
-//		t.Errorf("Expected saved HTML to have local URLs, got:\n%s\nExpected:\n%s", savedHTML, expectedHTML)
-//	}
-//
-//	// Проверяем счётчики
-//	if result.CountSuccess != 4 { // 1 HTML + 3 ресурса
-//		t.Errorf("Expected CountSuccess = 4, got %d", result.CountSuccess)
-//	}
-//	if result.CountError != 0 {
-//		t.Errorf("Expected CountError = 0, got %d", result.CountError)
-//	}
-//}
+func TestWebCrawler_Mirror_ConcurrentWorkersDownloadEachURLOnce(t *testing.T) {
+	html1 := "<html>index</html>"
+	responses := map[string][]byte{
+		"https://example.com": []byte(html1),
+	}
+	paths := map[string]string{
+		"https://example.com": "index.html",
+	}
+	links := []string{}
+	for i := 0; i < 20; i++ {
+		link := fmt.Sprintf("/page%d", i)
+		links = append(links, link, "/page0") // page0 встречается много раз
+		responses["https://example.com"+link] = []byte(fmt.Sprintf("<html>page %d</html>", i))
+		paths["https://example.com"+link] = fmt.Sprintf("page%d/index.html", i)
+	}
+
+	// Подготовка
+	mockDownloader := NewMockDownloader(responses, nil)
+	mockParser := &MockParserWithDynamicLinks{
+		linksMap: map[string][]string{
+			html1: links,
+		},
+	}
+	mockPathMapper := NewMockPathMapper(paths)
+	mockSaver := NewMockFileSaver(nil)
+
+	settings := webcrawler.WebCrawlerSettings{
+		MaxDepth:   2,
+		MaxWorkers: 8,
+	}
+
+	crawler := webcrawler.NewWebCrawler(
+		mockDownloader,
+		NewMockRegistry(mockParser),
+		mockPathMapper,
+		mockSaver,
+		settings,
+	)
+
+	// Вызов
+	result, err := crawler.Mirror(context.Background(), "https://example.com")
+
+	// Проверки
+	if err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+
+	// Каждый URL скачан ровно один раз
+	if len(mockDownloader.CallLog) != 21 {
+		t.Fatalf("Expected 21 calls to Download, got %d", len(mockDownloader.CallLog))
+	}
+	if len(mockSaver.GetSaved()) != 21 {
+		t.Fatalf("Expected 21 files to be saved, got %d", len(mockSaver.GetSaved()))
+	}
+
+	// Проверяем счётчики
+	if result.CountSuccess != 21 {
+		t.Errorf("Expected CountSuccess = 21, got %d", result.CountSuccess)
+	}
+	if result.CountError != 0 {
+		t.Errorf("Expected CountError = 0, got %d", result.CountError)
+	}
+}
+
+func TestWebCrawler_Mirror_StopsOnCanceledContext(t *testing.T) {
+	mockDownloader := NewMockDownloader(map[string][]byte{
+		"https://example.com": []byte("<html>test</html>"),
+	}, nil)
+
+	crawler := webcrawler.NewWebCrawler(
+		mockDownloader,
+		NewMockRegistry(NewMockHTMLParser([]string{}, []string{}, nil)),
+		NewMockPathMapper(map[string]string{}),
+		NewMockFileSaver(nil),
+		webcrawler.WebCrawlerSettings{MaxDepth: 1, MaxWorkers: 4},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Вызов
+	_, err := crawler.Mirror(ctx, "https://example.com")
+
+	// Проверки
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got: %v", err)
+	}
+	if len(mockDownloader.CallLog) != 0 {
+		t.Fatalf("Expected no calls to Download, got %d", len(mockDownloader.CallLog))
+	}
+}
+
+func TestWebCrawler_Mirror_ReplacesAbsoluteResourceURLsInHTML(t *testing.T) {
+	originalHTML := `<html>
+<head>
+    <link rel="stylesheet" href="https://example.com/style.css">
+    <link rel="icon" href="https://example.com/favicon.ico">
+</head>
+<body>
+    <img src="https://example.com/logo.png" alt="Logo">
+</body>
+</html>`
+
+	// Ожидаемый HTML после замены URL на локальные
+	expectedHTML := `<html>
+<head>
+    <link rel="stylesheet" href="style.css">
+    <link rel="icon" href="favicon.ico">
+</head>
+<body>
+    <img src="logo.png" alt="Logo">
+</body>
+</html>`
+
+	// Подготовка
+	mockDownloader := NewMockDownloader(map[string][]byte{
+		"https://example.com":             []byte(originalHTML),
+		"https://example.com/style.css":   []byte("body { color: red; }"),
+		"https://example.com/favicon.ico": []byte("ico data"),
+		"https://example.com/logo.png":    []byte("png data"),
+	}, nil)
+
+	mockPathMapper := NewMockPathMapper(map[string]string{
+		"https://example.com":             "index.html",
+		"https://example.com/style.css":   "style.css",
+		"https://example.com/favicon.ico": "favicon.ico",
+		"https://example.com/logo.png":    "logo.png",
+	})
+	mockSaver := NewMockFileSaver(nil)
+
+	settings := webcrawler.WebCrawlerSettings{
+		MaxDepth:     1,
+		MaxWorkers:   1,
+		ConvertLinks: true,
+	}
+
+	crawler := webcrawler.NewWebCrawler(
+		mockDownloader,
+		parser.DefaultRegistry(),
+		mockPathMapper,
+		mockSaver,
+		settings,
+	)
+
+	// Вызов
+	result, err := crawler.Mirror(context.Background(), "https://example.com")
+
+	// Проверки
+	if err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+
+	// Проверяем, что файлы сохранены
+	saved := mockSaver.GetSaved()
+	if _, ok := saved["index.html"]; !ok {
+		t.Fatalf("Expected file 'index.html' to be saved")
+	}
+
+	// Проверяем, что в index.html были заменены URL
+	savedHTML := string(saved["index.html"])
+	if savedHTML != expectedHTML {
+		t.Errorf("Expected saved HTML to have local URLs, got:\n%s\nExpected:\n%s", savedHTML, expectedHTML)
+	}
+
+	// Проверяем счётчики
+	if result.CountSuccess != 4 { // 1 HTML + 3 ресурса
+		t.Errorf("Expected CountSuccess = 4, got %d", result.CountSuccess)
+	}
+	if result.CountError != 0 {
+		t.Errorf("Expected CountError = 0, got %d", result.CountError)
+	}
+}
+
+func TestWebCrawler_Mirror_ConvertsLinksRelativeToReferencingPage(t *testing.T) {
+	originalHTML := `<a href="/docs/guide">Guide</a><a href='guide#intro'>Intro</a><a href="/blog/">Blog</a><img src=/logo.png>`
+	expectedHTML := `<a href="guide/index.html">Guide</a><a href='guide/index.html#intro'>Intro</a><a href="https://example.com/blog/">Blog</a><img src=../logo.png>`
+
+	// Подготовка
+	mockDownloader := NewMockDownloader(map[string][]byte{
+		"https://example.com/docs/":      []byte(originalHTML),
+		"https://example.com/docs/guide": []byte("<html>Guide</html>"),
+		"https://example.com/logo.png":   []byte("png data"),
+	}, nil)
+
+	mockPathMapper := NewMockPathMapper(map[string]string{
+		"https://example.com/docs/":      "docs/index.html",
+		"https://example.com/docs/guide": "docs/guide/index.html",
+		"https://example.com/logo.png":   "logo.png",
+	})
+	mockSaver := NewMockFileSaver(nil)
+
+	settings := webcrawler.WebCrawlerSettings{
+		MaxDepth:     2,
+		MaxWorkers:   1,
+		Scope:        webcrawler.Scope{NoParent: true},
+		ConvertLinks: true,
+	}
+
+	crawler := webcrawler.NewWebCrawler(
+		mockDownloader,
+		parser.DefaultRegistry(),
+		mockPathMapper,
+		mockSaver,
+		settings,
+	)
+
+	// Вызов
+	_, err := crawler.Mirror(context.Background(), "https://example.com/docs/")
+
+	// Проверки
+	if err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+
+	// Скачанные URL указывают на локальные файлы, остальные становятся абсолютными
+	savedHTML := string(mockSaver.GetSaved()["docs/index.html"])
+	if savedHTML != expectedHTML {
+		t.Errorf("Expected saved HTML to have converted URLs, got:\n%s\nExpected:\n%s", savedHTML, expectedHTML)
+	}
+}
+
+func TestWebCrawler_Mirror_DownloadsRequisitesFromStylesheets(t *testing.T) {
+	// Подготовка
+	mockDownloader := NewMockDownloader(map[string][]byte{
+		"https://example.com/":                      []byte(`<html><link rel="stylesheet" href="/css/site.css"><p style="background: url(/img/p.png)"></html>`),
+		"https://example.com/css/site.css":          []byte(`@import "theme.css"; body { background: url(../img/bg.png); }`),
+		"https://example.com/css/theme.css":         []byte(`@font-face { src: url(fonts/icons.woff2); }`),
+		"https://example.com/css/fonts/icons.woff2": []byte("font data"),
+		"https://example.com/img/bg.png":            []byte("png data"),
+		"https://example.com/img/p.png":             []byte("png data"),
+	}, nil)
+
+	crawler := webcrawler.NewWebCrawler(
+		mockDownloader,
+		parser.DefaultRegistry(),
+		NewMockPathMapper(map[string]string{}),
+		NewMockFileSaver(nil),
+		webcrawler.WebCrawlerSettings{MaxDepth: 1, MaxWorkers: 2},
+	)
+
+	// Вызов
+	result, err := crawler.Mirror(context.Background(), "https://example.com/")
+
+	// Проверки
+	if err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+
+	// Ссылки из CSS разрешаются относительно адреса самой таблицы стилей
+	for _, url := range []string{
+		"https://example.com/css/site.css",
+		"https://example.com/css/theme.css",
+		"https://example.com/css/fonts/icons.woff2",
+		"https://example.com/img/bg.png",
+		"https://example.com/img/p.png",
+	} {
+		if !mockDownloader.WasCalledWith(url) {
+			t.Errorf("Expected Download to be called with '%s'", url)
+		}
+	}
+
+	if result.CountSuccess != 6 {
+		t.Errorf("Expected CountSuccess = 6, got %d", result.CountSuccess)
+	}
+	if result.CountError != 0 {
+		t.Errorf("Expected CountError = 0, got %d", result.CountError)
+	}
+}
+
+func TestWebCrawler_Mirror_ParsesOnlyRegisteredContentTypes(t *testing.T) {
+	// Подготовка
+	mockDownloader := NewMockDownloader(map[string][]byte{
+		"https://example.com":          []byte("<html><img src='/logo.png'><a href='/file.zip'>zip</a></html>"),
+		"https://example.com/logo.png": []byte("\x89PNG\r\n\x1a\n<a href='/hidden'>"),
+		"https://example.com/file.zip": []byte("PK\x03\x04<a href='/hidden'>"),
+		"https://example.com/hidden":   []byte("<html>hidden</html>"),
+	}, nil)
+
+	crawler := webcrawler.NewWebCrawler(
+		mockDownloader,
+		parser.DefaultRegistry(),
+		NewMockPathMapper(map[string]string{}),
+		NewMockFileSaver(nil),
+		webcrawler.WebCrawlerSettings{MaxDepth: 3, MaxWorkers: 1},
+	)
+
+	// Вызов
+	result, err := crawler.Mirror(context.Background(), "https://example.com")
+
+	// Проверки
+	if err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+
+	// Ссылки из бинарных файлов не извлекаются
+	if mockDownloader.WasCalledWith("https://example.com/hidden") {
+		t.Fatalf("Download should not be called for a link found in binary content")
+	}
+	if result.CountSuccess != 3 {
+		t.Errorf("Expected CountSuccess = 3, got %d", result.CountSuccess)
+	}
+}
+
+func TestWebCrawler_Mirror_ContinuesInterruptedCrawl(t *testing.T) {
+	html1 := "<html>index</html>"
+	responses := map[string][]byte{
+		"https://example.com":       []byte(html1),
+		"https://example.com/page1": []byte("<html>1</html>"),
+		"https://example.com/page2": []byte("<html>2</html>"),
+		"https://example.com/page3": []byte("<html>3</html>"),
+		"https://example.com/page4": []byte("<html>4</html>"),
+	}
+	mockParser := &MockParserWithDynamicLinks{
+		linksMap: map[string][]string{
+			html1: {"/page1", "/page2", "/page3", "/page4"},
+		},
+	}
+	mockSaver := NewMockFileSaver(nil)
+	settings := webcrawler.WebCrawlerSettings{
+		MaxDepth:   2,
+		MaxWorkers: 1,
+		StateFile:  ".wget-state.json",
+	}
+
+	// Первый запуск прерывается на третьей загрузке
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	interrupted := &MockInterruptingDownloader{
+		MockDownloader: NewMockDownloader(responses, nil),
+		interruptAt:    3,
+		cancel:         cancel,
+	}
+	crawler := webcrawler.NewWebCrawler(interrupted, NewMockRegistry(mockParser), NewMockPathMapper(map[string]string{}), mockSaver, settings)
+
+	_, err := crawler.Mirror(ctx, "https://example.com")
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected context.Canceled, got: %v", err)
+	}
+	if _, ok := mockSaver.GetSaved()[".wget-state.json"]; !ok {
+		t.Fatalf("Expected crawl state to be saved")
+	}
+
+	// Второй запуск продолжает с места остановки
+	mockDownloader := NewMockDownloader(responses, nil)
+	settings.Continue = true
+	crawler = webcrawler.NewWebCrawler(mockDownloader, NewMockRegistry(mockParser), NewMockPathMapper(map[string]string{}), mockSaver, settings)
+
+	result, err := crawler.Mirror(context.Background(), "https://example.com")
+
+	if err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+	if mockDownloader.WasCalledWith("https://example.com") || mockDownloader.WasCalledWith("https://example.com/page1") {
+		t.Errorf("Already downloaded pages should not be downloaded again, got calls: %v", mockDownloader.CallLog)
+	}
+	if len(mockDownloader.CallLog) != 3 {
+		t.Errorf("Expected 3 remaining downloads, got: %v", mockDownloader.CallLog)
+	}
+	if result.CountSuccess != 5 {
+		t.Errorf("Expected CountSuccess = 5 across both runs, got %d", result.CountSuccess)
+	}
+	if result.CountError != 0 {
+		t.Errorf("Expected CountError = 0, got %d", result.CountError)
+	}
+}
+
+func TestWebCrawler_Mirror_ContinueRejectsStateOfAnotherSeed(t *testing.T) {
+	mockSaver := NewMockFileSaver(nil)
+	settings := webcrawler.WebCrawlerSettings{MaxDepth: 1, MaxWorkers: 1, StateFile: "state.json"}
+	newCrawler := func() *webcrawler.WebCrawler {
+		return webcrawler.NewWebCrawler(
+			NewMockDownloader(map[string][]byte{"https://example.com": []byte("<html></html>")}, nil),
+			NewMockRegistry(NewMockHTMLParser([]string{}, []string{}, nil)),
+			NewMockPathMapper(map[string]string{}),
+			mockSaver,
+			settings,
+		)
+	}
+
+	if _, err := newCrawler().Mirror(context.Background(), "https://example.com"); err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+
+	settings.Continue = true
+	_, err := newCrawler().Mirror(context.Background(), "https://other.example.com")
+
+	if err == nil {
+		t.Fatalf("Expected an error for a state file saved for another seed")
+	}
+}
+
+func TestWebCrawler_Mirror_DeduplicatesCanonicalURLs(t *testing.T) {
+	html1 := "<html>index</html>"
+
+	// Подготовка
+	mockDownloader := NewMockDownloader(map[string][]byte{
+		"https://example.com":              []byte(html1),
+		"https://example.com/page?x=1&y=2": []byte("<html>page</html>"),
+	}, nil)
+
+	mockParser := &MockParserWithDynamicLinks{
+		linksMap: map[string][]string{
+			html1: {
+				"/page?x=1&y=2",
+				"/page?y=2&x=1",
+				"/page?x=1&y=2#section",
+				"HTTPS://EXAMPLE.COM:443/page?x=1&y=2&utm_source=mail",
+				"https://example.com/",
+			},
+		},
+	}
+
+	settings := webcrawler.WebCrawlerSettings{
+		MaxDepth:   2,
+		MaxWorkers: 1,
+		Canonicalizer: webcrawler.Canonicalizer{
+			SortQuery:   true,
+			StripParams: webcrawler.DefaultTrackingParams,
+		},
+	}
+
+	crawler := webcrawler.NewWebCrawler(
+		mockDownloader,
+		NewMockRegistry(mockParser),
+		NewMockPathMapper(map[string]string{}),
+		NewMockFileSaver(nil),
+		settings,
+	)
+
+	// Вызов
+	result, err := crawler.Mirror(context.Background(), "https://example.com")
+
+	// Проверки
+	if err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+
+	// Все варианты написания одной страницы скачиваются один раз
+	if len(mockDownloader.CallLog) != 2 {
+		t.Fatalf("Expected 2 calls to Download, got %v", mockDownloader.CallLog)
+	}
+	if result.CountSuccess != 2 || result.CountError != 0 {
+		t.Errorf("Expected 2 successes and 0 errors, got %d and %d", result.CountSuccess, result.CountError)
+	}
+}
+
+func TestWebCrawler_Mirror_RespectsRobotsTxt(t *testing.T) {
+	// Подготовка
+	html1 := "<html>main page</html>"
+	mockDownloader := NewMockDownloader(map[string][]byte{
+		"https://example.com":            []byte(html1),
+		"https://example.com/robots.txt": []byte("User-agent: *\nDisallow: /private\n"),
+		"https://example.com/public":     []byte("<html>public</html>"),
+		"https://example.com/private":    []byte("<html>private</html>"),
+	}, nil)
+
+	mockParser := &MockParserWithDynamicLinks{
+		linksMap: map[string][]string{
+			html1: {"/public", "/private", "/private"},
+		},
+	}
+
+	settings := webcrawler.WebCrawlerSettings{
+		MaxDepth:   2,
+		MaxWorkers: 1,
+		Robots:     robots.NewChecker(mockDownloader, "wget-go/1.0"),
+	}
+
+	crawler := webcrawler.NewWebCrawler(
+		mockDownloader,
+		NewMockRegistry(mockParser),
+		NewMockPathMapper(map[string]string{}),
+		NewMockFileSaver(nil),
+		settings,
+	)
+
+	// Вызов
+	result, err := crawler.Mirror(context.Background(), "https://example.com")
+
+	// Проверки
+	if err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+	if mockDownloader.WasCalledWith("https://example.com/private") {
+		t.Error("Expected the URL disallowed by robots.txt not to be downloaded")
+	}
+	if !mockDownloader.WasCalledWith("https://example.com/public") {
+		t.Error("Expected the allowed URL to be downloaded")
+	}
+	if result.CountSuccess != 2 || result.CountError != 0 {
+		t.Errorf("Expected 2 successes and 0 errors, got %d and %d", result.CountSuccess, result.CountError)
+	}
+}
+
+func TestWebCrawler_Mirror_SeedsFromSitemaps(t *testing.T) {
+	// Подготовка
+	mockDownloader := NewMockDownloader(map[string][]byte{
+		"https://example.com": []byte("<html>main page</html>"),
+		"https://example.com/sitemap.xml": []byte(`<urlset>
+  <url><loc>https://example.com/new</loc><lastmod>2024-06-01</lastmod></url>
+  <url><loc>https://example.com/old</loc><lastmod>2023-01-01</lastmod></url>
+  <url><loc>https://example.com/undated</loc></url>
+  <url><loc>https://other.com/page</loc></url>
+</urlset>`),
+		"https://example.com/new":     []byte("<html>new</html>"),
+		"https://example.com/old":     []byte("<html>old</html>"),
+		"https://example.com/undated": []byte("<html>undated</html>"),
+		"https://other.com/page":      []byte("<html>other</html>"),
+	}, nil)
+
+	settings := webcrawler.WebCrawlerSettings{
+		MaxDepth:      1,
+		MaxWorkers:    2,
+		Sitemaps:      sitemap.NewCollector(mockDownloader, nil),
+		ModifiedSince: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	crawler := webcrawler.NewWebCrawler(
+		mockDownloader,
+		NewMockRegistry(NewMockHTMLParser(nil, []string{"/old"}, nil)),
+		NewMockPathMapper(map[string]string{}),
+		NewMockFileSaver(nil),
+		settings,
+	)
+
+	// Вызов
+	result, err := crawler.Mirror(context.Background(), "https://example.com")
+
+	// Проверки
+	if err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+	for _, u := range []string{"https://example.com/new", "https://example.com/undated"} {
+		if !mockDownloader.WasCalledWith(u) {
+			t.Errorf("Expected %s from the sitemap to be downloaded", u)
+		}
+	}
+	// Неизменившиеся страницы и страницы вне области не скачиваются
+	for _, u := range []string{"https://example.com/old", "https://other.com/page"} {
+		if mockDownloader.WasCalledWith(u) {
+			t.Errorf("Expected %s not to be downloaded", u)
+		}
+	}
+	if result.CountSuccess != 3 || result.CountError != 0 {
+		t.Errorf("Expected 3 successes and 0 errors, got %d and %d", result.CountSuccess, result.CountError)
+	}
+}
+
+func TestWebCrawler_Mirror_SavesButDoesNotParseOversizedDocuments(t *testing.T) {
+	// Подготовка
+	large := "<html>" + strings.Repeat("x", 100) + "</html>"
+	mockDownloader := NewMockDownloader(map[string][]byte{
+		"https://example.com":           []byte("<html>main page</html>"),
+		"https://example.com/large":     []byte(large),
+		"https://example.com/from-page": []byte("<html>linked</html>"),
+	}, nil)
+
+	mockParser := &MockParserWithDynamicLinks{
+		linksMap: map[string][]string{
+			"<html>main page</html>": {"/large"},
+			large:                    {"/from-page"},
+		},
+	}
+	mockFileSaver := NewMockFileSaver(nil)
+
+	settings := webcrawler.WebCrawlerSettings{
+		MaxDepth:     3,
+		MaxWorkers:   1,
+		MaxParseSize: 50,
+	}
+
+	crawler := webcrawler.NewWebCrawler(
+		mockDownloader,
+		NewMockRegistry(mockParser),
+		NewMockPathMapper(map[string]string{"https://example.com/large": "large.html"}),
+		mockFileSaver,
+		settings,
+	)
+
+	// Вызов
+	result, err := crawler.Mirror(context.Background(), "https://example.com")
+
+	// Проверки
+	if err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+	if string(mockFileSaver.GetSaved()["large.html"]) != large {
+		t.Error("Expected the oversized document to be saved in full")
+	}
+	if mockDownloader.WasCalledWith("https://example.com/from-page") {
+		t.Error("Expected links of the oversized document not to be followed")
+	}
+	if result.CountSuccess != 2 {
+		t.Errorf("Expected 2 successes, got %d", result.CountSuccess)
+	}
+}
+
+func newPolitenessTestCrawler(d downloader.Downloader, settings webcrawler.WebCrawlerSettings) *webcrawler.WebCrawler {
+	return webcrawler.NewWebCrawler(
+		d,
+		NewMockRegistry(NewMockHTMLParser(nil, []string{"/a", "/b", "/c"}, nil)),
+		NewMockPathMapper(map[string]string{}),
+		NewMockFileSaver(nil),
+		settings,
+	)
+}
+
+func politenessTestPages() map[string][]byte {
+	return map[string][]byte{
+		"https://example.com":   []byte("<html>main</html>"),
+		"https://example.com/a": []byte("<html>a</html>"),
+		"https://example.com/b": []byte("<html>b</html>"),
+		"https://example.com/c": []byte("<html>c</html>"),
+	}
+}
+
+func TestWebCrawler_Mirror_LimitsConnectionsPerHost(t *testing.T) {
+	// Подготовка
+	mockDownloader := &MockSlowDownloader{
+		MockDownloader: NewMockDownloader(politenessTestPages(), nil),
+		delay:          20 * time.Millisecond,
+	}
+	crawler := newPolitenessTestCrawler(mockDownloader, webcrawler.WebCrawlerSettings{
+		MaxDepth:   2,
+		MaxWorkers: 4,
+		Politeness: webcrawler.Politeness{MaxConnectionsPerHost: 1},
+	})
+
+	// Вызов
+	result, err := crawler.Mirror(context.Background(), "https://example.com")
+
+	// Проверки
+	if err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+	if result.CountSuccess != 4 {
+		t.Errorf("Expected 4 successes, got %d", result.CountSuccess)
+	}
+	if mockDownloader.MaxActive != 1 {
+		t.Errorf("Expected at most 1 concurrent request, got %d", mockDownloader.MaxActive)
+	}
+}
+
+func TestWebCrawler_Mirror_DelaysRequestsToHost(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings webcrawler.WebCrawlerSettings
+		robots   string
+	}{
+		{
+			name:     "MinDelay",
+			settings: webcrawler.WebCrawlerSettings{Politeness: webcrawler.Politeness{MinDelay: 30 * time.Millisecond}},
+		},
+		{
+			name:     "RequestsPerSecond",
+			settings: webcrawler.WebCrawlerSettings{Politeness: webcrawler.Politeness{RequestsPerSecond: 1000.0 / 30}},
+		},
+		{
+			name:   "Crawl-delay из robots.txt",
+			robots: "User-agent: *\nCrawl-delay: 0.03\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Подготовка
+			pages := politenessTestPages()
+			if tt.robots != "" {
+				pages["https://example.com/robots.txt"] = []byte(tt.robots)
+			}
+			mockDownloader := &MockSlowDownloader{MockDownloader: NewMockDownloader(pages, nil)}
+			settings := tt.settings
+			settings.MaxDepth = 2
+			settings.MaxWorkers = 4
+			if tt.robots != "" {
+				settings.Robots = robots.NewChecker(mockDownloader.MockDownloader, "wget-go")
+			}
+
+			// Вызов
+			_, err := newPolitenessTestCrawler(mockDownloader, settings).Mirror(context.Background(), "https://example.com")
+
+			// Проверки
+			if err != nil {
+				t.Fatalf("Mirror returned an error: %v", err)
+			}
+			started := mockDownloader.Started
+			if len(started) != 4 {
+				t.Fatalf("Expected 4 requests, got %d", len(started))
+			}
+			// Четыре запроса занимают не меньше трёх интервалов
+			if elapsed := started[3].Sub(started[0]); elapsed < 85*time.Millisecond {
+				t.Errorf("Expected requests to be spread over at least 90ms, got %v", elapsed)
+			}
+		})
+	}
+}
+
+func TestWebCrawler_Mirror_CrawlsEverySeedInItsOwnScope(t *testing.T) {
+	// Подготовка
+	pageA := "<html>a</html>"
+	pageB := "<html>b</html>"
+	mockDownloader := NewMockDownloader(map[string][]byte{
+		"https://a.com":          []byte(pageA),
+		"https://a.com/guide":    []byte("<html>a guide</html>"),
+		"https://b.com":          []byte(pageB),
+		"https://b.com/shared":   []byte("<html>shared</html>"),
+		"https://other.com/page": []byte("<html>other</html>"),
+	}, nil)
+
+	mockParser := &MockParserWithDynamicLinks{
+		linksMap: map[string][]string{
+			pageA: {"/guide", "https://b.com/shared", "https://other.com/page"},
+			pageB: {"/shared"},
+		},
+	}
+
+	crawler := webcrawler.NewWebCrawler(
+		mockDownloader,
+		NewMockRegistry(mockParser),
+		NewMockPathMapper(map[string]string{}),
+		NewMockFileSaver(nil),
+		webcrawler.WebCrawlerSettings{MaxDepth: 2, MaxWorkers: 2},
+	)
+
+	// Вызов
+	result, err := crawler.Mirror(context.Background(), "https://a.com", "https://b.com", "https://a.com")
+
+	// Проверки
+	if err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+	// b.com/shared в области второго сида и скачивается один раз
+	assert.ElementsMatch(t, []string{
+		"https://a.com",
+		"https://b.com",
+		"https://a.com/guide",
+		"https://b.com/shared",
+	}, mockDownloader.CallLog)
+	if result.CountSuccess != 4 || result.CountError != 0 {
+		t.Errorf("Expected 4 successes and 0 errors, got %d and %d", result.CountSuccess, result.CountError)
+	}
+
+	if _, err := crawler.Mirror(context.Background()); err == nil {
+		t.Error("Expected an error without seeds")
+	}
+}
+
+func TestWebCrawler_Mirror_BoundsRequisiteChains(t *testing.T) {
+	// Подготовка: цепочка rel=next и HTML, полученный как ресурс
+	pages := map[string][]byte{
+		"https://example.com/docs/1": []byte(`<html><link rel="next" href="/docs/2">
+<link rel="alternate" href="/other/feed.html"><link rel="stylesheet" href="/docs/a.css">
+<script src="/docs/app"></script><iframe src="/docs/frame.html"></iframe></html>`),
+		"https://example.com/docs/2":          []byte(`<html><link rel="next" href="/docs/3"></html>`),
+		"https://example.com/docs/3":          []byte(`<html>3</html>`),
+		"https://example.com/other/feed.html": []byte(`<html>feed</html>`),
+		"https://example.com/docs/a.css":      []byte(`@import "/b.css";`),
+		"https://example.com/b.css":           []byte(`body { background: url(/docs/bg.png) }`),
+		"https://example.com/docs/bg.png":     []byte("png"),
+		"https://example.com/docs/app":        []byte(`<html><a href="/docs/9">9</a><link rel="stylesheet" href="/docs/x.css"></html>`),
+		"https://example.com/docs/x.css":      []byte(`body {}`),
+		"https://example.com/docs/frame.html": []byte(`<html><img src="/docs/f.png"><iframe src="/docs/inner.html"></iframe></html>`),
+		"https://example.com/docs/f.png":      []byte("png"),
+		"https://example.com/docs/inner.html": []byte(`<html><img src="/docs/deep.png"></html>`),
+		"https://example.com/docs/deep.png":   []byte("png"),
+	}
+	mockDownloader := NewMockDownloader(pages, nil)
+	crawler := webcrawler.NewWebCrawler(
+		mockDownloader,
+		parser.DefaultRegistry(),
+		NewMockPathMapper(map[string]string{}),
+		NewMockFileSaver(nil),
+		webcrawler.WebCrawlerSettings{MaxDepth: 1, MaxWorkers: 2, Scope: webcrawler.Scope{NoParent: true}},
+	)
+
+	// Вызов
+	_, err := crawler.Mirror(context.Background(), "https://example.com/docs/1")
+
+	// Проверки: ресурсы и встроенные документы скачаны, ссылки из них — нет
+	if err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+	for _, path := range []string{"/docs/1", "/docs/a.css", "/b.css", "/docs/bg.png", "/docs/app", "/docs/frame.html", "/docs/f.png", "/docs/inner.html"} {
+		if !mockDownloader.WasCalledWith("https://example.com" + path) {
+			t.Errorf("Expected %s to be downloaded", path)
+		}
+	}
+	for _, path := range []string{"/docs/2", "/docs/3", "/other/feed.html", "/docs/9", "/docs/x.css", "/docs/deep.png"} {
+		if mockDownloader.WasCalledWith("https://example.com" + path) {
+			t.Errorf("Expected %s not to be downloaded", path)
+		}
+	}
+}
+
+func TestWebCrawler_Mirror_ContinueDoesNotConvertLinksTwice(t *testing.T) {
+	// Подготовка
+	mockDownloader := NewMockDownloader(map[string][]byte{
+		"https://example.com/docs/": []byte(`<html><link rel="stylesheet" href="https://cdn.net/s.css"><a href="/about">About</a></html>`),
+		"https://example.com/about": []byte(`<html>About</html>`),
+		"https://cdn.net/s.css":     []byte(`body {}`),
+	}, nil)
+	mockSaver := NewMockFileSaver(nil)
+	mirror := func() {
+		t.Helper()
+		crawler := webcrawler.NewWebCrawler(mockDownloader, parser.DefaultRegistry(), &pathmapper.FilePathMapper{NoHostDirectories: true}, mockSaver,
+			webcrawler.WebCrawlerSettings{MaxDepth: 2, MaxWorkers: 1, PageRequisites: true, ConvertLinks: true, StateFile: "state.json", Continue: true})
+		if _, err := crawler.Mirror(context.Background(), "https://example.com/docs/"); err != nil {
+			t.Fatalf("Mirror returned an error: %v", err)
+		}
+	}
+
+	// Вызов: повторный запуск по завершённому зеркалу
+	mirror()
+	converted := string(mockSaver.GetSaved()["docs/index.html"])
+	mirror()
+
+	// Проверки
+	expected := `<html><link rel="stylesheet" href="../cdn.net/s.css"><a href="../about/index.html">About</a></html>`
+	if converted != expected {
+		t.Errorf("Expected converted links after the first run, got:\n%s", converted)
+	}
+	if again := string(mockSaver.GetSaved()["docs/index.html"]); again != converted {
+		t.Errorf("Expected the second run to leave the page unchanged, got:\n%s", again)
+	}
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"testing"
+	"wget/parser"
+)
+
+func referenceURLs(refs []parser.Reference) []string {
+	urls := []string{}
+	for _, ref := range refs {
+		urls = append(urls, ref.URL)
+	}
+	return urls
+}
+
+func TestCSSParser_ParseCSS_ExtractsUrlsAndImports(t *testing.T) {
+	css := `@import "base.css";
+@import url('print.css') print;
+/* background: url(commented.png); */
+@font-face { src: url( "fonts/icons.woff2" ) format("woff2"), URL(fonts/icons.woff); }
+.logo { background: url(/img/logo.png) no-repeat; }
+.data { background: url(data:image/png;base64,AAAA); }
+.filter { filter: url(#blur); }`
+
+	p := &parser.CssParser{}
+
+	refs, err := p.ParseCSS([]byte(css))
+
+	if err != nil {
+		t.Fatalf("ParseCSS returned an error: %v", err)
+	}
+
+	expected := []string{"base.css", "print.css", "fonts/icons.woff2", "fonts/icons.woff", "/img/logo.png"}
+	assertEqualSlices(t, referenceURLs(refs), expected)
+
+	if refs[0].Tag != "@import" || refs[1].Tag != "@import" || refs[2].Tag != "url" {
+		t.Errorf("Expected @import, @import, url tags, got %s, %s, %s", refs[0].Tag, refs[1].Tag, refs[2].Tag)
+	}
+	for _, ref := range refs {
+		if ref.Kind != parser.KindRequisite {
+			t.Errorf("Expected %s to be a requisite", ref.URL)
+		}
+		if raw := css[ref.Start:ref.End]; raw != ref.URL {
+			t.Errorf("Expected offsets of %s to point at it, got %q", ref.URL, raw)
+		}
+	}
+}
+
+func TestHTMLParser_ParseHTML_ExtractsInlineStyles(t *testing.T) {
+	html := `<html><head><style>
+body { background: url("/img/bg.png"); }
+</style></head>
+<body><div style="background-image: url(&quot;/img/div.png&quot;)">Hi</div></body></html>`
+	p := &parser.HtmlParser{}
+
+	refs, err := p.ParseHTML([]byte(html))
+
+	if err != nil {
+		t.Fatalf("ParseHTML returned an error: %v", err)
+	}
+
+	assertEqualSlices(t, referenceURLs(refs), []string{"/img/bg.png", "/img/div.png"})
+
+	if refs[0].Attr != "" || refs[1].Attr != "style" {
+		t.Errorf("Expected attributes '' and 'style', got %q and %q", refs[0].Attr, refs[1].Attr)
+	}
+	if raw := html[refs[0].Start:refs[0].End]; raw != "/img/bg.png" {
+		t.Errorf("Expected offsets to point at /img/bg.png, got %q", raw)
+	}
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"wget/downloader"
+	"wget/fetcher"
+)
+
+func TestFileName(t *testing.T) {
+	cases := []struct {
+		url         string
+		disposition string
+		expected    string
+	}{
+		{"https://example.com/files/report.pdf?x=1", "", "report.pdf"},
+		{"https://example.com/files/my%20file.txt", "", "my file.txt"},
+		{"https://example.com/", "", "index.html"},
+		{"https://example.com", "", "index.html"},
+		{"https://example.com/download?id=7", `attachment; filename="data.csv"`, "data.csv"},
+		{"https://example.com/download", `attachment; filename*=UTF-8''%D0%BE%D1%82%D1%87%D1%91%D1%82.txt`, "отчёт.txt"},
+		// Имя из заголовка не выводит файл за пределы каталога
+		{"https://example.com/download", `attachment; filename="../../etc/passwd"`, "passwd"},
+		{"https://example.com/get", `attachment; filename=".."`, "get"},
+		{"https://example.com/get", `inline`, "get"},
+	}
+
+	for _, c := range cases {
+		header := http.Header{}
+		if c.disposition != "" {
+			header.Set("Content-Disposition", c.disposition)
+		}
+		if got := fetcher.FileName(c.url, header); got != c.expected {
+			t.Errorf("FileName(%s, %q) = %q, expected %q", c.url, c.disposition, got, c.expected)
+		}
+	}
+}
+
+func TestFetcher_Fetch_NumbersExistingFiles(t *testing.T) {
+	// Подготовка
+	mockDownloader := NewMockDownloader(map[string][]byte{
+		"https://example.com/file.txt":        []byte("first"),
+		"https://mirror.example.com/file.txt": []byte("second"),
+	}, nil)
+	mockFileSaver := NewMockFileSaver(nil)
+	f := fetcher.NewFetcher(mockDownloader, mockFileSaver, nil)
+
+	// Вызов
+	var names []string
+	for _, u := range []string{"https://example.com/file.txt", "https://mirror.example.com/file.txt", "https://example.com/file.txt"} {
+		name, err := f.Fetch(context.Background(), u)
+		if err != nil {
+			t.Fatalf("Fetch returned an error: %v", err)
+		}
+		names = append(names, name)
+	}
+
+	// Проверки
+	expected := []string{"file.txt", "file.txt.1", "file.txt.2"}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("Expected names %v, got %v", expected, names)
+			break
+		}
+	}
+	if string(mockFileSaver.GetSaved()["file.txt.1"]) != "second" {
+		t.Errorf("Expected the second document in file.txt.1")
+	}
+}
+
+func TestFetcher_Fetch_WritesToOutput(t *testing.T) {
+	mockDownloader := NewMockDownloader(map[string][]byte{
+		"https://example.com/a": []byte("first\n"),
+		"https://example.com/b": []byte("second\n"),
+	}, nil)
+	mockFileSaver := NewMockFileSaver(nil)
+	var output bytes.Buffer
+	f := fetcher.NewFetcher(mockDownloader, mockFileSaver, &output)
+
+	for _, u := range []string{"https://example.com/a", "https://example.com/b"} {
+		if name, err := f.Fetch(context.Background(), u); err != nil || name != "" {
+			t.Fatalf("Fetch returned %q, %v", name, err)
+		}
+	}
+
+	// Документы записываются подряд, как при wget -O
+	if output.String() != "first\nsecond\n" {
+		t.Errorf("Unexpected output %q", output.String())
+	}
+	if len(mockFileSaver.GetSaved()) != 0 {
+		t.Errorf("Expected no files to be saved, got %v", mockFileSaver.GetSaved())
+	}
+}
+
+func TestFetcher_Fetch_SavesNothingWhenNotModified(t *testing.T) {
+	// Подготовка: сервер отвечает 304 на заданный пользователем заголовок
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer server.Close()
+	mockFileSaver := NewMockFileSaver(nil)
+	var output bytes.Buffer
+
+	for _, out := range []*bytes.Buffer{nil, &output} {
+		f := fetcher.NewFetcher(&downloader.HTTPDownloader{}, mockFileSaver, nil)
+		if out != nil {
+			f.Output = out
+		}
+
+		// Вызов
+		name, err := f.Fetch(context.Background(), server.URL+"/file.txt")
+
+		// Проверки
+		if !errors.Is(err, fetcher.ErrNotModified) || name != "" {
+			t.Errorf("Expected ErrNotModified and no name, got %q, %v", name, err)
+		}
+	}
+	if len(mockFileSaver.GetSaved()) != 0 || output.Len() != 0 {
+		t.Errorf("Expected nothing saved, got %v and %q", mockFileSaver.GetSaved(), output.String())
+	}
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"wget/storage"
+)
+
+func TestOsFileSaver_SaveAndLoad(t *testing.T) {
+	dir := t.TempDir()
+	s := &storage.OsFileSaver{OutputDir: dir}
+
+	if err := s.Save("dir/index.html", strings.NewReader("first")); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+	if err := s.Save("dir/index.html", strings.NewReader("second")); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+
+	data, err := s.Load("dir/index.html")
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	if string(data) != "second" {
+		t.Errorf("Expected 'second', got %q", data)
+	}
+
+	// Временные файлы не остаются в каталоге
+	entries, _ := os.ReadDir(dir + "/dir")
+	if len(entries) != 1 {
+		t.Errorf("Expected only index.html in the directory, got %d entries", len(entries))
+	}
+
+	if _, err := s.Load("missing.html"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected fs.ErrNotExist for a missing file, got: %v", err)
+	}
+}
+
+func TestOsFileSaver_Save_KeepsPartialFileOnReadError(t *testing.T) {
+	dir := t.TempDir()
+	s := &storage.OsFileSaver{OutputDir: dir}
+
+	if err := s.Save("file.iso", strings.NewReader("complete")); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+
+	// Обрыв соединения посреди скачивания
+	broken := io.MultiReader(strings.NewReader("new ver"), iotest.ErrReader(io.ErrUnexpectedEOF))
+	if err := s.Save("file.iso", broken); err == nil {
+		t.Fatal("Expected the read error to be returned")
+	}
+
+	data, _ := s.Load("file.iso")
+	if string(data) != "complete" {
+		t.Errorf("Expected the previous file to be kept, got %q", data)
+	}
+	if size := s.Partial("file.iso"); size != 7 {
+		t.Fatalf("Expected a partial file of 7 bytes, got %d", size)
+	}
+
+	// Докачка продолжает частичный файл с указанного места
+	if err := s.Resume("file.iso", 3, strings.NewReader(" version")); err != nil {
+		t.Fatalf("Resume returned an error: %v", err)
+	}
+	data, _ = s.Load("file.iso")
+	if string(data) != "new version" {
+		t.Errorf("Expected the resumed file, got %q", data)
+	}
+	if size := s.Partial("file.iso"); size != 0 {
+		t.Errorf("Expected the partial file to be gone, got %d bytes", size)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only file.iso in the directory, got %d entries", len(entries))
+	}
+
+	if err := s.Resume("other.iso", 10, strings.NewReader("x")); err == nil {
+		t.Error("Expected an error when resuming past the end of the partial file")
+	}
+}
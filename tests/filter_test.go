@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"wget/parser"
+	"wget/webcrawler"
+)
+
+func TestFilter_CheckName_MatchesExtensionsAndGlobs(t *testing.T) {
+	filter := webcrawler.Filter{Accept: []string{"pdf", ".HTML", "report-*.csv"}, Reject: []string{"*draft*"}}
+
+	cases := map[string]bool{
+		"https://example.com/a/report.PDF":        true,
+		"https://example.com/index.html":          true,
+		"https://example.com/report-2024.csv":     true,
+		"https://example.com/data.csv":            false,
+		"https://example.com/archive.zip":         false,
+		"https://example.com/draft.pdf":           false,
+		"https://example.com/dir/":                false, // у каталога нет имени файла
+		"https://example.com/pdf":                 false,
+		"https://example.com/page.html?file=x.gz": true,
+	}
+	for rawUrl, expected := range cases {
+		if got := filter.CheckName(rawUrl) == ""; got != expected {
+			t.Errorf("CheckName(%s) passes = %v, expected %v", rawUrl, got, expected)
+		}
+	}
+}
+
+func TestFilter_CheckURL_AppliesDirectoriesAndRegexps(t *testing.T) {
+	filter := webcrawler.Filter{
+		IncludeDirectories: []string{"/docs", "/*/manual"},
+		ExcludeDirectories: []string{"/docs/print"},
+		RejectRegex:        regexp.MustCompile(`[?&]sort=`),
+	}
+
+	cases := map[string]bool{
+		"https://example.com/docs/":                true,
+		"https://example.com/docs/a/b.html":        true,
+		"https://example.com/docs/print/a.html":    false,
+		"https://example.com/docs/printable.html":  true,
+		"https://example.com/en/manual/intro.html": true,
+		"https://example.com/en/manual/x/y.html":   true,
+		"https://example.com/en/guide/intro.html":  false,
+		"https://example.com/documents/a.html":     false,
+		"https://example.com/index.html":           false,
+		"https://example.com/docs/list?sort=name":  false,
+	}
+	for rawUrl, expected := range cases {
+		if got := filter.CheckURL(rawUrl) == ""; got != expected {
+			t.Errorf("CheckURL(%s) passes = %v, expected %v", rawUrl, got, expected)
+		}
+	}
+
+	accepting := webcrawler.Filter{AcceptRegex: regexp.MustCompile(`^https://example\.com/`)}
+	if rule := accepting.CheckURL("https://other.com/"); !strings.Contains(rule, "accept regex") {
+		t.Errorf("Expected the accept regex rule, got %q", rule)
+	}
+}
+
+func TestFilter_CheckType_MatchesMediaTypes(t *testing.T) {
+	filter := webcrawler.Filter{AcceptTypes: []string{"text/*", "application/pdf"}, RejectTypes: []string{"text/css"}}
+
+	cases := map[string]bool{
+		"text/html; charset=utf-8": true,
+		"TEXT/PLAIN":               true,
+		"application/pdf":          true,
+		"text/css":                 false,
+		"image/png":                false,
+		"application/pdf-like":     false,
+	}
+	for contentType, expected := range cases {
+		if got := filter.CheckType(contentType) == ""; got != expected {
+			t.Errorf("CheckType(%s) passes = %v, expected %v", contentType, got, expected)
+		}
+	}
+}
+
+func filterSite() map[string][]byte {
+	return map[string][]byte{
+		"https://example.com/": []byte(`<a href="/docs/guide.html">guide</a> <img src="/logo.png"> <a href="/print/guide.html">print</a>
+<a href="/files/archive.zip">zip</a> <a href="/files/report.pdf">pdf</a> <a href="/media/clip.mp4">clip</a>`),
+		"https://example.com/docs/guide.html":   []byte(`<a href="/files/manual.pdf">manual</a>`),
+		"https://example.com/print/guide.html":  []byte(`<p>print</p>`),
+		"https://example.com/files/archive.zip": []byte("PK"),
+		"https://example.com/files/report.pdf":  []byte("%PDF report"),
+		"https://example.com/files/manual.pdf":  []byte("%PDF manual"),
+		"https://example.com/media/clip.mp4":    []byte("mp4"),
+		"https://example.com/logo.png":          []byte("png"),
+	}
+}
+
+func mirrorWithFilter(t *testing.T, filter webcrawler.Filter) (*MockDownloader, []string, string) {
+	t.Helper()
+	mockDownloader := NewMockDownloader(filterSite(), nil)
+	paths := map[string]string{}
+	for rawUrl := range filterSite() {
+		paths[rawUrl] = strings.TrimPrefix(rawUrl, "https://example.com/")
+	}
+	mockSaver := NewMockFileSaver(nil)
+	var logs bytes.Buffer
+	crawler := webcrawler.NewWebCrawler(mockDownloader, parser.DefaultRegistry(), NewMockPathMapper(paths), mockSaver,
+		webcrawler.WebCrawlerSettings{MaxDepth: 3, MaxWorkers: 2, Filter: filter, Logger: log.New(&logs, "", 0)})
+
+	if _, err := crawler.Mirror(context.Background(), "https://example.com/"); err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+	var saved []string
+	for path := range mockSaver.GetSaved() {
+		saved = append(saved, path)
+	}
+	slices.Sort(saved)
+	return mockDownloader, saved, logs.String()
+}
+
+func TestWebCrawler_Mirror_SkipsFilteredLinksAndTypes(t *testing.T) {
+	mockDownloader, saved, logs := mirrorWithFilter(t, webcrawler.Filter{
+		Reject:             []string{"zip"},
+		ExcludeDirectories: []string{"/print"},
+		RejectTypes:        []string{"video/*"},
+	})
+
+	assertEqualSlices(t, saved, []string{"", "docs/guide.html", "files/manual.pdf", "files/report.pdf", "logo.png"})
+	// отклонённые по ссылке не запрашиваются, по типу — запрашиваются
+	for _, rawUrl := range []string{"https://example.com/files/archive.zip", "https://example.com/print/guide.html"} {
+		if mockDownloader.WasCalledWith(rawUrl) {
+			t.Errorf("Expected %s not to be downloaded", rawUrl)
+		}
+	}
+	for _, expected := range []string{
+		`Rejected https://example.com/files/archive.zip: reject pattern "zip"`,
+		`Rejected https://example.com/print/guide.html: excluded directory "/print"`,
+		`Not saving https://example.com/media/clip.mp4: reject type "video/*"`,
+	} {
+		if !strings.Contains(logs, expected) {
+			t.Errorf("Expected log %q, got:\n%s", expected, logs)
+		}
+	}
+}
+
+func TestWebCrawler_Mirror_AcceptListStillTraversesPages(t *testing.T) {
+	mockDownloader, saved, logs := mirrorWithFilter(t, webcrawler.Filter{Accept: []string{"pdf"}})
+
+	// страницы читаются ради ссылок, но не сохраняются
+	assertEqualSlices(t, saved, []string{"files/manual.pdf", "files/report.pdf"})
+	if mockDownloader.WasCalledWith("https://example.com/logo.png") {
+		t.Error("Expected the image not to be downloaded")
+	}
+	if !strings.Contains(logs, `Not saving https://example.com/docs/guide.html: file name "guide.html" is not accepted`) {
+		t.Errorf("Expected the page to be reported as not saved, got:\n%s", logs)
+	}
+}
+
+func TestWebCrawler_Mirror_AcceptTypesStillTraversesPages(t *testing.T) {
+	mockDownloader, saved, logs := mirrorWithFilter(t, webcrawler.Filter{AcceptTypes: []string{"image/*"}})
+
+	// страницы отклонены по типу, но их ссылки всё равно обходятся
+	assertEqualSlices(t, saved, []string{"logo.png"})
+	if !mockDownloader.WasCalledWith("https://example.com/files/manual.pdf") {
+		t.Error("Expected the links of a page rejected by type to be followed")
+	}
+	if !strings.Contains(logs, `Not saving https://example.com/: type "text/html" is not accepted`) {
+		t.Errorf("Expected the page to be reported as not saved, got:\n%s", logs)
+	}
+}
@@ -18,6 +18,19 @@ func assertEqualSlices(t *testing.T, actual, expected []string) {
 	}
 }
 
+// Вспомогательная функция: делит ссылки на ресурсы и страницы
+func splitReferences(refs []parser.Reference) (resources []string, links []string) {
+	resources, links = []string{}, []string{}
+	for _, ref := range refs {
+		if ref.Kind == parser.KindPage {
+			links = append(links, ref.URL)
+		} else {
+			resources = append(resources, ref.URL)
+		}
+	}
+	return resources, links
+}
+
 func TestHTMLParser_ParseHTML_ExtractsResourcesAndLinks(t *testing.T) {
 	html := `
 <html>
@@ -38,7 +51,8 @@ func TestHTMLParser_ParseHTML_ExtractsResourcesAndLinks(t *testing.T) {
 
 	p := &parser.HtmlParser{} // или как у тебя реализовано
 
-	resources, links, err := p.ParseHTML([]byte(html))
+	refs, err := p.ParseHTML([]byte(html))
+	resources, links := splitReferences(refs)
 
 	if err != nil {
 		t.Fatalf("ParseHTML returned an error: %v", err)
@@ -52,7 +66,8 @@ func TestHTMLParser_ParseHTML_ExtractsResourcesAndLinks(t *testing.T) {
 func TestHTMLParser_ParseHTML_EmptyHTML(t *testing.T) {
 	p := &parser.HtmlParser{}
 
-	resources, links, err := p.ParseHTML([]byte(""))
+	refs, err := p.ParseHTML([]byte(""))
+	resources, links := splitReferences(refs)
 
 	if err != nil {
 		t.Fatalf("ParseHTML returned an error: %v", err)
@@ -70,7 +85,8 @@ func TestHTMLParser_ParseHTML_NoLinksOrResources(t *testing.T) {
 	html := "<html><body><h1>Hello</h1></body></html>"
 	p := &parser.HtmlParser{}
 
-	resources, links, err := p.ParseHTML([]byte(html))
+	refs, err := p.ParseHTML([]byte(html))
+	resources, links := splitReferences(refs)
 
 	if err != nil {
 		t.Fatalf("ParseHTML returned an error: %v", err)
@@ -88,7 +104,8 @@ func TestHTMLParser_ParseHTML_InvalidHTML(t *testing.T) {
 	invalidHTML := "<html><body><h1>Unclosed tag</body></html>" // intentionally broken
 	p := &parser.HtmlParser{}
 
-	resources, links, err := p.ParseHTML([]byte(invalidHTML))
+	refs, err := p.ParseHTML([]byte(invalidHTML))
+	resources, links := splitReferences(refs)
 
 	if err != nil {
 		t.Fatalf("ParseHTML should not return error on invalid HTML, got: %v", err)
@@ -117,7 +134,8 @@ func TestHTMLParser_ParseHTML_IgnoresIgnoredSchemesEverywhere(t *testing.T) {
 	</html>`
 	p := &parser.HtmlParser{}
 
-	resources, links, err := p.ParseHTML([]byte(html))
+	refs, err := p.ParseHTML([]byte(html))
+	resources, links := splitReferences(refs)
 
 	if err != nil {
 		t.Fatalf("ParseHTML returned an error: %v", err)
@@ -132,3 +150,88 @@ func TestHTMLParser_ParseHTML_IgnoresIgnoredSchemesEverywhere(t *testing.T) {
 		t.Errorf("Expected 0 resources, got %d", len(resources))
 	}
 }
+
+func TestHTMLParser_ParseHTML_ReturnsReferenceDetails(t *testing.T) {
+	html := `<head><link rel="stylesheet" media=print href='/print.css'></head>` +
+		`<a href="/a?x=1&amp;y=2">A</a><iframe src=/frame></iframe>`
+	p := &parser.HtmlParser{}
+
+	refs, err := p.ParseHTML([]byte(html))
+
+	if err != nil {
+		t.Fatalf("ParseHTML returned an error: %v", err)
+	}
+	if len(refs) != 3 {
+		t.Fatalf("Expected 3 references, got %d", len(refs))
+	}
+
+	expected := []struct {
+		url  string
+		tag  string
+		attr string
+		kind parser.Kind
+		raw  string
+	}{
+		{"/print.css", "link", "href", parser.KindRequisite, "/print.css"},
+		{"/a?x=1&y=2", "a", "href", parser.KindPage, "/a?x=1&amp;y=2"},
+		{"/frame", "iframe", "src", parser.KindEmbedded, "/frame"},
+	}
+	for i, e := range expected {
+		ref := refs[i]
+		if ref.URL != e.url || ref.Tag != e.tag || ref.Attr != e.attr || ref.Kind != e.kind {
+			t.Errorf("Unexpected reference at index %d: %+v", i, ref)
+		}
+		// Смещения указывают на исходное значение атрибута без кавычек
+		if raw := html[ref.Start:ref.End]; raw != e.raw {
+			t.Errorf("Expected raw value %q at index %d, got %q", e.raw, i, raw)
+		}
+	}
+
+	if refs[0].Attrs["rel"] != "stylesheet" || refs[0].Attrs["media"] != "print" {
+		t.Errorf("Expected rel and media attributes, got %v", refs[0].Attrs)
+	}
+}
+
+func TestHTMLParser_ParseHTML_ClassifiesLinksByRel(t *testing.T) {
+	cases := map[string]parser.Kind{
+		"stylesheet":           parser.KindRequisite,
+		"Alternate StyleSheet": parser.KindRequisite,
+		"icon":                 parser.KindRequisite,
+		"shortcut icon":        parser.KindRequisite,
+		"apple-touch-icon":     parser.KindRequisite,
+		"preload":              parser.KindRequisite,
+		"modulepreload":        parser.KindRequisite,
+		"manifest":             parser.KindRequisite,
+		"next":                 parser.KindPage,
+		"prev":                 parser.KindPage,
+		"alternate":            parser.KindPage,
+		"canonical":            parser.KindPage,
+		"":                     parser.KindPage,
+	}
+	p := &parser.HtmlParser{}
+
+	for rel, expected := range cases {
+		refs, err := p.ParseHTML([]byte(`<link rel="` + rel + `" href="/target">`))
+		if err != nil {
+			t.Fatalf("ParseHTML returned an error: %v", err)
+		}
+		if len(refs) != 1 || refs[0].Kind != expected {
+			t.Errorf("rel=%q: expected one reference of kind %v, got %+v", rel, expected, refs)
+		}
+	}
+}
+
+func TestHTMLParser_ParseHTML_IgnoresConnectionHints(t *testing.T) {
+	p := &parser.HtmlParser{}
+
+	// подсказки только открывают соединения, скачивать по ним нечего
+	refs, err := p.ParseHTML([]byte(`<link rel="dns-prefetch" href="//cdn.example.net">` +
+		`<link rel="preconnect" href="https://fonts.example.net" crossorigin>`))
+
+	if err != nil {
+		t.Fatalf("ParseHTML returned an error: %v", err)
+	}
+	if len(refs) != 0 {
+		t.Errorf("Expected no references, got %+v", refs)
+	}
+}
@@ -0,0 +1,211 @@
+package tests
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"wget/downloader"
+)
+
+func TestHTTPDownloader_Download_ReturnsMetadataAfterRedirect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+			return
+		}
+		w.Header().Set("Content-Type", "text/css")
+		_, _ = w.Write([]byte("body {}"))
+	}))
+	defer server.Close()
+
+	d := &downloader.HTTPDownloader{}
+
+	response, err := d.Download(context.Background(), server.URL+"/old")
+
+	if err != nil {
+		t.Fatalf("Download returned an error: %v", err)
+	}
+	if response.URL != server.URL+"/new" {
+		t.Errorf("Expected final URL %s/new, got %s", server.URL, response.URL)
+	}
+	if response.ContentType != "text/css" {
+		t.Errorf("Expected Content-Type text/css, got %s", response.ContentType)
+	}
+	if body := readBody(t, response); body != "body {}" {
+		t.Errorf("Unexpected body %q", body)
+	}
+}
+
+func TestHTTPDownloader_Download_StatusError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	d := &downloader.HTTPDownloader{}
+
+	_, err := d.Download(context.Background(), server.URL+"/missing")
+
+	var downloadErr *downloader.DownloadError
+	if !errors.As(err, &downloadErr) || downloadErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected DownloadError with status 404, got: %v", err)
+	}
+}
+
+func TestHTTPDownloader_Download_SendsUserAgentAndHeaders(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	d, err := downloader.NewHTTPDownloader(downloader.HTTPDownloaderSettings{
+		UserAgent: "test-agent",
+		Header:    http.Header{"X-Token": {"secret"}},
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPDownloader returned an error: %v", err)
+	}
+
+	if _, err := d.Download(context.Background(), server.URL); err != nil {
+		t.Fatalf("Download returned an error: %v", err)
+	}
+
+	if got.Get("User-Agent") != "test-agent" {
+		t.Errorf("Expected User-Agent test-agent, got %q", got.Get("User-Agent"))
+	}
+	if got.Get("X-Token") != "secret" {
+		t.Errorf("Expected X-Token header, got %q", got.Get("X-Token"))
+	}
+}
+
+func TestHTTPDownloader_Download_LimitsRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Path+"x", http.StatusFound)
+	}))
+	defer server.Close()
+
+	d, _ := downloader.NewHTTPDownloader(downloader.HTTPDownloaderSettings{MaxRedirects: 2})
+
+	_, err := d.Download(context.Background(), server.URL+"/")
+
+	if err == nil || !strings.Contains(err.Error(), "stopped after 2 redirects") {
+		t.Fatalf("Expected redirect limit error, got: %v", err)
+	}
+}
+
+func TestHTTPDownloader_Download_ReadTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("start"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	d, _ := downloader.NewHTTPDownloader(downloader.HTTPDownloaderSettings{ReadTimeout: 50 * time.Millisecond})
+
+	response, err := d.Download(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Download returned an error: %v", err)
+	}
+	defer response.Body.Close()
+
+	// Тайм-аут бездействия срабатывает при чтении тела
+	_, err = io.ReadAll(response.Body)
+
+	if !errors.Is(err, downloader.ErrReadTimeout) {
+		t.Fatalf("Expected ErrReadTimeout, got: %v", err)
+	}
+}
+
+func TestHTTPDownloader_Download_TLSVerification(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	// Самоподписанный сертификат не проходит проверку по умолчанию
+	d, _ := downloader.NewHTTPDownloader(downloader.HTTPDownloaderSettings{})
+	if _, err := d.Download(context.Background(), server.URL); err == nil {
+		t.Fatalf("Expected certificate verification error")
+	}
+
+	d, _ = downloader.NewHTTPDownloader(downloader.HTTPDownloaderSettings{InsecureSkipVerify: true})
+	if _, err := d.Download(context.Background(), server.URL); err != nil {
+		t.Fatalf("Download with InsecureSkipVerify returned an error: %v", err)
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	pemData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, pemData, 0644); err != nil {
+		t.Fatal(err)
+	}
+	d, err := downloader.NewHTTPDownloader(downloader.HTTPDownloaderSettings{CACertFile: caFile})
+	if err != nil {
+		t.Fatalf("NewHTTPDownloader returned an error: %v", err)
+	}
+	if _, err := d.Download(context.Background(), server.URL); err != nil {
+		t.Fatalf("Download with custom CA returned an error: %v", err)
+	}
+}
+
+func TestHTTPDownloader_Download_UsesProxy(t *testing.T) {
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.URL.Host
+		_, _ = w.Write([]byte("via proxy"))
+	}))
+	defer proxy.Close()
+
+	d, _ := downloader.NewHTTPDownloader(downloader.HTTPDownloaderSettings{ProxyURL: proxy.URL})
+
+	response, err := d.Download(context.Background(), "http://internal.example/page")
+
+	if err != nil {
+		t.Fatalf("Download returned an error: %v", err)
+	}
+	if body := readBody(t, response); proxiedHost != "internal.example" || body != "via proxy" {
+		t.Errorf("Expected request through proxy, got host %q and body %q", proxiedHost, body)
+	}
+}
+
+func TestHTTPDownloader_Download_SendsConditions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` && r.Header.Get("If-Modified-Since") == "Mon, 01 Jan 2024 00:00:00 GMT" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte("changed"))
+	}))
+	defer server.Close()
+
+	d := &downloader.HTTPDownloader{}
+	conditions := downloader.Conditions{ETag: `"v1"`, LastModified: "Mon, 01 Jan 2024 00:00:00 GMT"}
+
+	response, err := d.Download(downloader.WithConditions(context.Background(), conditions), server.URL)
+
+	if err != nil {
+		t.Fatalf("Download returned an error: %v", err)
+	}
+	if body := readBody(t, response); !response.NotModified || body != "" {
+		t.Errorf("Expected an empty not modified response, got %v and %q", response.NotModified, body)
+	}
+
+	// Без условий документ скачивается заново
+	response, err = d.Download(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Download returned an error: %v", err)
+	}
+	if body := readBody(t, response); response.NotModified || body != "changed" {
+		t.Errorf("Expected a full response, got %v and %q", response.NotModified, body)
+	}
+}
@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"wget/cookies"
+	"wget/downloader"
+	"wget/parser"
+	"wget/pathmapper"
+	"wget/webcrawler"
+)
+
+// intranet — сайт с формой входа, CSRF-токеном и сессией в cookie.
+type intranet struct {
+	mu        sync.Mutex
+	requested []string
+	loggedOut bool
+}
+
+func (s *intranet) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requested = append(s.requested, r.Method+" "+r.URL.Path)
+	s.mu.Unlock()
+
+	session, _ := r.Cookie("session")
+	authorized := session != nil && session.Value == "s1"
+	w.Header().Set("Content-Type", "text/html")
+	switch {
+	case r.URL.Path == "/login" && r.Method == http.MethodGet:
+		http.SetCookie(w, &http.Cookie{Name: "csrf", Value: "c1", Path: "/"})
+		_, _ = w.Write([]byte(`<form id="search" action="/search"><input name="q"></form>
+<form method="POST">
+  <input type="hidden" name="csrf_token" value="c1">
+  <input name="username"><input type="password" name="password">
+  <input type="checkbox" name="remember" value="yes" checked>
+  <input type="checkbox" name="newsletter">
+  <button type="submit" name="action" value="login">Sign in</button>
+</form>`))
+	case r.URL.Path == "/login":
+		csrf, _ := r.Cookie("csrf")
+		if csrf == nil || r.FormValue("csrf_token") != csrf.Value || r.FormValue("username") != "alice" ||
+			r.FormValue("password") != "secret" || r.FormValue("remember") != "yes" ||
+			r.FormValue("action") != "login" || r.Form.Has("newsletter") {
+			_, _ = w.Write([]byte(`<form method="POST"><input type="password" name="password"></form>`))
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1", Path: "/"})
+		http.Redirect(w, r, "/home", http.StatusSeeOther)
+	case r.URL.Path == "/logout" || r.URL.Path == "/session/end":
+		s.mu.Lock()
+		s.loggedOut = true
+		s.mu.Unlock()
+		http.SetCookie(w, &http.Cookie{Name: "session", MaxAge: -1, Path: "/"})
+	case !authorized:
+		http.Redirect(w, r, "/login", http.StatusFound)
+	case r.URL.Path == "/home":
+		_, _ = w.Write([]byte(`<a href="/logout">Выход</a> <a href="/session/end" class="btn sign-out">x</a> <a href="/docs">Docs</a>`))
+	case r.URL.Path == "/docs":
+		_, _ = w.Write([]byte(`<p>internal docs</p>`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newLoginCrawler(t *testing.T, serverUrl string, fields map[string]string, saver *MockFileSaver) *webcrawler.WebCrawler {
+	t.Helper()
+	d, err := downloader.NewHTTPDownloader(downloader.HTTPDownloaderSettings{Jar: cookies.NewJar()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return webcrawler.NewWebCrawler(d, parser.DefaultRegistry(), &pathmapper.FilePathMapper{}, saver,
+		webcrawler.WebCrawlerSettings{
+			MaxDepth:   3,
+			MaxWorkers: 2,
+			Login: &webcrawler.Login{
+				URL:       serverUrl + "/login",
+				Fields:    fields,
+				Submitter: d,
+			},
+		})
+}
+
+func TestWebCrawler_Mirror_LogsInAndAvoidsLogoutLinks(t *testing.T) {
+	site := &intranet{}
+	server := httptest.NewServer(site)
+	defer server.Close()
+	saver := NewMockFileSaver(nil)
+	crawler := newLoginCrawler(t, server.URL, map[string]string{"username": "alice", "password": "secret"}, saver)
+
+	result, err := crawler.Mirror(context.Background(), server.URL+"/home")
+
+	if err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+	if result.CountSuccess != 2 || result.CountError != 0 {
+		t.Errorf("Expected 2 pages without errors, got %+v", result)
+	}
+	if site.loggedOut {
+		t.Errorf("Expected logout links not to be followed, requests: %v", site.requested)
+	}
+	var docs string
+	for path, data := range saver.GetSaved() {
+		if strings.HasSuffix(path, "docs/index.html") {
+			docs = string(data)
+		}
+	}
+	if docs != "<p>internal docs</p>" {
+		t.Errorf("Expected the docs behind the login to be saved, got %q", docs)
+	}
+}
+
+func TestWebCrawler_Mirror_FailsWhenLoginIsRejected(t *testing.T) {
+	site := &intranet{}
+	server := httptest.NewServer(site)
+	defer server.Close()
+	crawler := newLoginCrawler(t, server.URL, map[string]string{"username": "alice", "password": "wrong"}, NewMockFileSaver(nil))
+
+	_, err := crawler.Mirror(context.Background(), server.URL+"/home")
+
+	if err == nil || !strings.Contains(err.Error(), "asks for a password again") {
+		t.Errorf("Expected a login error, got %v", err)
+	}
+	for _, request := range site.requested {
+		if request == "GET /home" {
+			t.Error("Expected no crawling after a failed login")
+		}
+	}
+}
+
+func TestDefaultLogoutPattern_MatchesWholeWords(t *testing.T) {
+	cases := map[string]bool{
+		"/logout":                    true,
+		"/account/sign-out":          true,
+		"/log_out?next=/":            true,
+		"/LogOff.aspx":               true,
+		"btn sign out":               true,
+		"/logout_confirm":            true,
+		"/user/logoutAll":            true,
+		"logout_btn":                 true,
+		"/auth/log_out_user":         true,
+		"/logout2":                   true,
+		"/blog-offers/":              false,
+		"/catalog-outdoor/tents":     false,
+		"/changelog_off-by-one.html": false,
+		"/design-outline/":           false,
+	}
+
+	for value, expected := range cases {
+		if webcrawler.DefaultLogoutPattern.MatchString(value) != expected {
+			t.Errorf("Expected match %v for %q", expected, value)
+		}
+	}
+}
+
+func TestHTMLParser_ParseForms_ReadsFieldsOfEachForm(t *testing.T) {
+	p := &parser.HtmlParser{}
+
+	forms := p.ParseForms([]byte(`<input name="outside">
+<FORM Action="/login?next=%2F&amp;x=1" METHOD=post>
+  <input type=hidden name=token value="a&amp;b">
+  <input name="user">
+  <input type="checkbox" name="remember" checked>
+  <button>Go</button>
+</form>
+<form><input type="password" name="pin"></form>`))
+
+	if len(forms) != 2 {
+		t.Fatalf("Expected 2 forms, got %d", len(forms))
+	}
+	login := forms[0]
+	if login.Action != "/login?next=%2F&x=1" || login.Method != "post" || login.HasPassword() {
+		t.Errorf("Unexpected form %+v", login)
+	}
+	expected := []parser.FormField{
+		{Name: "token", Type: "hidden", Value: "a&b"},
+		{Name: "user", Type: "text"},
+		{Name: "remember", Type: "checkbox", Checked: true},
+		{Type: "submit"},
+	}
+	if len(login.Fields) != len(expected) {
+		t.Fatalf("Expected fields %+v, got %+v", expected, login.Fields)
+	}
+	for i := range expected {
+		if login.Fields[i] != expected[i] {
+			t.Errorf("Field %d: expected %+v, got %+v", i, expected[i], login.Fields[i])
+		}
+	}
+	if forms[1].Method != "get" || !forms[1].HasPassword() {
+		t.Errorf("Expected a GET form with a password, got %+v", forms[1])
+	}
+}
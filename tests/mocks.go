@@ -1,12 +1,26 @@
 package tests
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sync"
+	"testing"
+	"time"
+	"wget/downloader"
+	"wget/parser"
 )
 
 // MockDownloader — заглушка для скачивания
 type MockDownloader struct {
+	mu        sync.Mutex
 	responses map[string][]byte
 	err       error
 	CallLog   []string // лог вызовов
@@ -20,7 +34,9 @@ func NewMockDownloader(responses map[string][]byte, err error) *MockDownloader {
 	}
 }
 
-func (m *MockDownloader) Download(ctx context.Context, url string) ([]byte, error) {
+func (m *MockDownloader) Download(ctx context.Context, url string) (*downloader.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.CallLog = append(m.CallLog, url)
 	if m.err != nil {
 		return nil, m.err
@@ -29,10 +45,35 @@ func (m *MockDownloader) Download(ctx context.Context, url string) ([]byte, erro
 	if !ok {
 		return nil, errors.New("URL not found in mock responses")
 	}
-	return data, nil
+	return mockResponse(url, data), nil
+}
+
+// mockResponse угадывает Content-Type по расширению или содержимому, как это делает веб-сервер
+func mockResponse(rawUrl string, data []byte) *downloader.Response {
+	contentType := ""
+	if u, err := url.Parse(rawUrl); err == nil {
+		contentType = mime.TypeByExtension(path.Ext(u.Path))
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return &downloader.Response{URL: rawUrl, ContentType: contentType, Body: io.NopCloser(bytes.NewReader(data))}
+}
+
+// readBody читает тело ответа целиком
+func readBody(t *testing.T, response *downloader.Response) string {
+	t.Helper()
+	data, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		t.Fatalf("Reading the body failed: %v", err)
+	}
+	return string(data)
 }
 
 func (m *MockDownloader) WasCalledWith(url string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	for _, u := range m.CallLog {
 		if u == url {
 			return true
@@ -56,8 +97,16 @@ func NewMockHTMLParser(resources, links []string, err error) *MockHTMLParser {
 	}
 }
 
-func (m *MockHTMLParser) ParseHTML(data []byte) (resources []string, links []string, err error) {
-	return m.resources, m.links, m.err
+func (m *MockHTMLParser) Parse(data []byte) ([]parser.Reference, error) {
+	return append(mockReferences(m.resources, parser.KindRequisite), mockReferences(m.links, parser.KindPage)...), m.err
+}
+
+func mockReferences(urls []string, kind parser.Kind) []parser.Reference {
+	refs := make([]parser.Reference, 0, len(urls))
+	for _, url := range urls {
+		refs = append(refs, parser.Reference{URL: url, Kind: kind})
+	}
+	return refs
 }
 
 // MockPathMapper — заглушка для генерации локальных путей
@@ -78,26 +127,79 @@ func (m *MockPathMapper) Map(url string) string {
 
 // MockFileSaver — заглушка для сохранения файлов
 type MockFileSaver struct {
-	saved map[string][]byte
-	err   error
+	mu       sync.Mutex
+	saved    map[string][]byte
+	partials map[string][]byte
+	modTimes map[string]time.Time
+	err      error
 }
 
 func NewMockFileSaver(err error) *MockFileSaver {
 	return &MockFileSaver{
-		saved: make(map[string][]byte),
-		err:   err,
+		saved:    make(map[string][]byte),
+		partials: make(map[string][]byte),
+		modTimes: make(map[string]time.Time),
+		err:      err,
 	}
 }
 
-func (m *MockFileSaver) Save(path string, data []byte) error {
+func (m *MockFileSaver) Save(path string, data io.Reader) error {
+	return m.Resume(path, 0, data)
+}
+
+func (m *MockFileSaver) Resume(path string, offset int64, data io.Reader) error {
 	if m.err != nil {
 		return m.err
 	}
-	m.saved[path] = data
+	content, err := io.ReadAll(data)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	content = append(m.partials[path][:offset:offset], content...)
+	if err != nil {
+		m.partials[path] = content
+		return err
+	}
+	delete(m.partials, path)
+	m.saved[path] = content
+	return nil
+}
+
+func (m *MockFileSaver) Partial(path string) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return int64(len(m.partials[path]))
+}
+
+func (m *MockFileSaver) Load(path string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.saved[path]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", path, fs.ErrNotExist)
+	}
+	return data, nil
+}
+
+func (m *MockFileSaver) Exists(path string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.saved[path]
+	return ok
+}
+
+func (m *MockFileSaver) SetModTime(path string, modTime time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.saved[path]; !ok {
+		return fmt.Errorf("%s: %w", path, fs.ErrNotExist)
+	}
+	m.modTimes[path] = modTime
 	return nil
 }
 
 func (m *MockFileSaver) GetSaved() map[string][]byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	return m.saved
 }
 
@@ -106,24 +208,28 @@ type MockParserWithDynamicLinks struct {
 	linksMap map[string][]string
 }
 
-func (m *MockParserWithDynamicLinks) ParseHTML(data []byte) (resources []string, links []string, err error) {
-	content := string(data)
-	for key, links := range m.linksMap {
-		if content == key {
-			return []string{}, links, nil
-		}
-	}
-	return []string{}, []string{}, nil
+func (m *MockParserWithDynamicLinks) Parse(data []byte) ([]parser.Reference, error) {
+	return mockReferences(m.linksMap[string(data)], parser.KindPage), nil
+}
+
+// NewMockRegistry регистрирует парсер для HTML-ответов
+func NewMockRegistry(p parser.Parser) *parser.Registry {
+	registry := parser.NewRegistry()
+	registry.Register("text/html", p)
+	return registry
 }
 
 // MockDownloaderWithSomeErrors — позволяет указать, какие URL возвращают ошибки
 type MockDownloaderWithSomeErrors struct {
+	mu        sync.Mutex
 	responses map[string][]byte
 	errors    map[string]error
 	CallLog   []string
 }
 
-func (m *MockDownloaderWithSomeErrors) Download(ctx context.Context, url string) ([]byte, error) {
+func (m *MockDownloaderWithSomeErrors) Download(ctx context.Context, url string) (*downloader.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.CallLog = append(m.CallLog, url)
 
 	if err, hasErr := m.errors[url]; hasErr {
@@ -135,10 +241,12 @@ func (m *MockDownloaderWithSomeErrors) Download(ctx context.Context, url string)
 		return nil, errors.New("URL not found in mock responses")
 	}
 
-	return data, nil
+	return mockResponse(url, data), nil
 }
 
 func (m *MockDownloaderWithSomeErrors) WasCalledWith(url string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	for _, u := range m.CallLog {
 		if u == url {
 			return true
@@ -146,3 +254,47 @@ func (m *MockDownloaderWithSomeErrors) WasCalledWith(url string) bool {
 	}
 	return false
 }
+
+// MockInterruptingDownloader — отменяет контекст на заданном по счёту вызове, имитируя прерывание
+type MockInterruptingDownloader struct {
+	*MockDownloader
+	interruptAt int
+	cancel      context.CancelFunc
+	calls       int
+}
+
+func (m *MockInterruptingDownloader) Download(ctx context.Context, url string) (*downloader.Response, error) {
+	m.calls++
+	if m.calls == m.interruptAt {
+		m.cancel()
+		return nil, ctx.Err()
+	}
+	return m.MockDownloader.Download(ctx, url)
+}
+
+// MockSlowDownloader — отвечает с задержкой, запоминает время начала запросов
+// и наибольшее число одновременных запросов
+type MockSlowDownloader struct {
+	*MockDownloader
+	delay time.Duration
+
+	mu        sync.Mutex
+	active    int
+	MaxActive int
+	Started   []time.Time
+}
+
+func (m *MockSlowDownloader) Download(ctx context.Context, url string) (*downloader.Response, error) {
+	m.mu.Lock()
+	m.active++
+	m.MaxActive = max(m.MaxActive, m.active)
+	m.Started = append(m.Started, time.Now())
+	m.mu.Unlock()
+
+	time.Sleep(m.delay)
+
+	m.mu.Lock()
+	m.active--
+	m.mu.Unlock()
+	return m.MockDownloader.Download(ctx, url)
+}
@@ -10,8 +10,8 @@ func TestPathMapper_Map_Root(t *testing.T) {
 
 	path := p.Map("https://example.com/")
 
-	if path != "index.html" {
-		t.Errorf("Expected 'index.html', got '%s'", path)
+	if path != "example.com/index.html" {
+		t.Errorf("Expected 'example.com/index.html', got '%s'", path)
 	}
 }
 
@@ -20,8 +20,8 @@ func TestPathMapper_Map_Page(t *testing.T) {
 
 	path := p.Map("https://example.com/page")
 
-	if path != "page/index.html" {
-		t.Errorf("Expected 'page/index.html', got '%s'", path)
+	if path != "example.com/page/index.html" {
+		t.Errorf("Expected 'example.com/page/index.html', got '%s'", path)
 	}
 }
 
@@ -30,8 +30,8 @@ func TestPathMapper_Map_FileWithExtension(t *testing.T) {
 
 	path := p.Map("https://example.com/style.css")
 
-	if path != "style.css" {
-		t.Errorf("Expected 'style.css', got '%s'", path)
+	if path != "example.com/style.css" {
+		t.Errorf("Expected 'example.com/style.css', got '%s'", path)
 	}
 }
 
@@ -40,8 +40,8 @@ func TestPathMapper_Map_PageWithQuery(t *testing.T) {
 
 	path := p.Map("https://example.com/page?param=1")
 
-	if path != "page/index.html" {
-		t.Errorf("Expected 'page/index.html', got '%s'", path)
+	if path != "example.com/page/index.html" {
+		t.Errorf("Expected 'example.com/page/index.html', got '%s'", path)
 	}
 }
 
@@ -50,8 +50,8 @@ func TestPathMapper_Map_PageWithFragment(t *testing.T) {
 
 	path := p.Map("https://example.com/page#section")
 
-	if path != "page/index.html" {
-		t.Errorf("Expected 'page/index.html', got '%s'", path)
+	if path != "example.com/page/index.html" {
+		t.Errorf("Expected 'example.com/page/index.html', got '%s'", path)
 	}
 }
 
@@ -60,7 +60,73 @@ func TestPathMapper_Map_Directory(t *testing.T) {
 
 	path := p.Map("https://example.com/dir/")
 
-	if path != "dir/index.html" {
-		t.Errorf("Expected 'dir/index.html', got '%s'", path)
+	if path != "example.com/dir/index.html" {
+		t.Errorf("Expected 'example.com/dir/index.html', got '%s'", path)
 	}
 }
+
+func assertMappings(t *testing.T, p *pathmapper.FilePathMapper, cases [][2]string) {
+	t.Helper()
+	for _, c := range cases {
+		if path := p.Map(c[0]); path != c[1] {
+			t.Errorf("Map(%s) = '%s', expected '%s'", c[0], path, c[1])
+		}
+	}
+}
+
+func TestPathMapper_Map_SeparatesHostsAndPorts(t *testing.T) {
+	assertMappings(t, &pathmapper.FilePathMapper{}, [][2]string{
+		{"https://Example.com:443/a.css", "example.com/a.css"},
+		{"http://example.com:80/", "example.com/index.html"},
+		{"http://example.com:8080/", "example.com:8080/index.html"},
+		{"https://cdn.example.net/img/logo.png", "cdn.example.net/img/logo.png"},
+		{"https://example.com/a/../../etc/passwd.txt", "example.com/etc/passwd.txt"},
+	})
+}
+
+func TestPathMapper_Map_LayoutOptions(t *testing.T) {
+	assertMappings(t, &pathmapper.FilePathMapper{NoHostDirectories: true}, [][2]string{
+		{"https://example.com/docs/a.html", "docs/a.html"},
+		{"https://example.com/", "index.html"},
+	})
+	assertMappings(t, &pathmapper.FilePathMapper{ProtocolDirectories: true}, [][2]string{
+		{"https://example.com/docs/a.html", "https/example.com/docs/a.html"},
+		{"http://example.com:8080/", "http/example.com:8080/index.html"},
+	})
+	// каталоги отрезаются до имени файла, но не дальше
+	assertMappings(t, &pathmapper.FilePathMapper{CutDirs: 2}, [][2]string{
+		{"https://example.com/pub/gnu/wget/wget.tar.gz", "example.com/wget/wget.tar.gz"},
+		{"https://example.com/pub/manual", "example.com/index.html"},
+		{"https://example.com/a.html", "example.com/a.html"},
+	})
+	assertMappings(t, &pathmapper.FilePathMapper{NoHostDirectories: true, CutDirs: 1}, [][2]string{
+		{"https://example.com/pub/gnu/", "gnu/index.html"},
+	})
+}
+
+func TestPathMapper_Map_NoDirectoriesNumbersCollisions(t *testing.T) {
+	assertMappings(t, &pathmapper.FilePathMapper{NoDirectories: true}, [][2]string{
+		{"https://example.com/", "index.html"},
+		{"https://example.com/docs/", "index.html.1"},
+		{"https://other.org/", "index.html.2"},
+		{"https://example.com/?page=2", "index.html"}, // тот же документ
+		{"https://example.com/docs/a.css", "a.css"},
+		{"https://other.org/a.css", "a.css.1"},
+		{"https://example.com/docs/", "index.html.1"},
+	})
+}
+
+func TestPathMapper_Restore_KeepsNumbersOfEarlierRun(t *testing.T) {
+	first := &pathmapper.FilePathMapper{NoDirectories: true}
+	first.Map("https://example.com/")
+	first.Map("https://example.com/docs/")
+
+	// следующий запуск встречает документы в другом порядке
+	second := &pathmapper.FilePathMapper{NoDirectories: true}
+	second.Restore(first.Assignments())
+	assertMappings(t, second, [][2]string{
+		{"https://example.com/docs/", "index.html.1"},
+		{"https://other.org/", "index.html.2"},
+		{"https://example.com/", "index.html"},
+	})
+}
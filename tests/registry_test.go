@@ -0,0 +1,22 @@
+package tests
+
+import (
+	"testing"
+	"wget/parser"
+)
+
+func TestRegistry_Lookup_MatchesMediaTypeIgnoringParameters(t *testing.T) {
+	registry := parser.DefaultRegistry()
+
+	for _, contentType := range []string{"text/html", "text/html; charset=utf-8", "TEXT/HTML;charset=UTF-8", "text/css"} {
+		if _, ok := registry.Lookup(contentType); !ok {
+			t.Errorf("Expected a parser for %q", contentType)
+		}
+	}
+
+	for _, contentType := range []string{"image/png", "application/zip", "application/pdf", ""} {
+		if _, ok := registry.Lookup(contentType); ok {
+			t.Errorf("Expected no parser for %q", contentType)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"wget/parser"
+	"wget/pathmapper"
+	"wget/webcrawler"
+)
+
+// cdnSite — страница с ресурсами на CDN и ссылкой на другой сайт.
+func cdnSite() map[string][]byte {
+	return map[string][]byte{
+		"https://example.com/": []byte(`<a href="/about">About</a> <a href="https://other.org/page">Other</a>
+<img src="https://cdn.example.net/img/logo.png"> <link rel="stylesheet" href="https://static.other.org/site.css">
+<script src="https://tracker.test/t.js"></script> <img src="/local.png">`),
+		"https://example.com/about":             []byte(`<p>About</p>`),
+		"https://example.com/local.png":         []byte("png"),
+		"https://other.org/page":                []byte(`<p>Other</p>`),
+		"https://cdn.example.net/img/logo.png":  []byte("png"),
+		"https://static.other.org/site.css":     []byte(`body { background: url(fonts/bg.png) }`),
+		"https://static.other.org/fonts/bg.png": []byte("png"),
+		"https://tracker.test/t.js":             []byte("track()"),
+	}
+}
+
+func mirrorCdnSite(t *testing.T, settings webcrawler.WebCrawlerSettings) (*MockDownloader, []string) {
+	t.Helper()
+	mockDownloader := NewMockDownloader(cdnSite(), nil)
+	mockSaver := NewMockFileSaver(nil)
+	settings.MaxDepth = 3
+	settings.MaxWorkers = 2
+	crawler := webcrawler.NewWebCrawler(mockDownloader, parser.DefaultRegistry(), &pathmapper.FilePathMapper{}, mockSaver, settings)
+
+	result, err := crawler.Mirror(context.Background(), "https://example.com/")
+	if err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+	if result.CountError != 0 {
+		t.Errorf("Expected no errors, got %d", result.CountError)
+	}
+	var saved []string
+	for path := range mockSaver.GetSaved() {
+		saved = append(saved, path)
+	}
+	slices.Sort(saved)
+	return mockDownloader, saved
+}
+
+func TestWebCrawler_Mirror_FetchesRequisitesFromScopeOnly(t *testing.T) {
+	mockDownloader, saved := mirrorCdnSite(t, webcrawler.WebCrawlerSettings{})
+
+	assertEqualSlices(t, saved, []string{"example.com/about/index.html", "example.com/index.html", "example.com/local.png"})
+	if mockDownloader.WasCalledWith("https://cdn.example.net/img/logo.png") {
+		t.Error("Expected off-site requisites not to be fetched without PageRequisites")
+	}
+}
+
+func TestWebCrawler_Mirror_PageRequisitesFromAnyHost(t *testing.T) {
+	mockDownloader, saved := mirrorCdnSite(t, webcrawler.WebCrawlerSettings{PageRequisites: true})
+
+	// ресурсы с других хостов сохраняются в каталогах с именем хоста
+	assertEqualSlices(t, saved, []string{
+		"cdn.example.net/img/logo.png",
+		"example.com/about/index.html",
+		"example.com/index.html",
+		"example.com/local.png",
+		"static.other.org/fonts/bg.png",
+		"static.other.org/site.css",
+		"tracker.test/t.js",
+	})
+	if mockDownloader.WasCalledWith("https://other.org/page") {
+		t.Error("Expected links to stay in scope")
+	}
+}
+
+func TestWebCrawler_Mirror_PageRequisitesFromAllowedHosts(t *testing.T) {
+	mockDownloader, saved := mirrorCdnSite(t, webcrawler.WebCrawlerSettings{
+		PageRequisites: true,
+		RequisiteHosts: []string{"example.net", "other.org"},
+		Scope:          webcrawler.Scope{DenyHosts: []string{"static.other.org"}},
+	})
+
+	assertEqualSlices(t, saved, []string{
+		"cdn.example.net/img/logo.png",
+		"example.com/about/index.html",
+		"example.com/index.html",
+		"example.com/local.png",
+	})
+	for _, rawUrl := range []string{"https://tracker.test/t.js", "https://static.other.org/site.css", "https://other.org/page"} {
+		if mockDownloader.WasCalledWith(rawUrl) {
+			t.Errorf("Expected %s not to be fetched", rawUrl)
+		}
+	}
+}
+
+func TestWebCrawler_Mirror_NoHostDirectoriesKeepsRequisiteHosts(t *testing.T) {
+	mockDownloader := NewMockDownloader(map[string][]byte{
+		"https://example.com/": []byte(`<head><link rel="stylesheet" href="/style.css">
+<link rel="stylesheet" href="https://cdn.example.net/style.css"></head>`),
+		"https://example.com/style.css":     []byte("body { color: red }"),
+		"https://cdn.example.net/style.css": []byte("body { color: blue }"),
+	}, nil)
+	mockSaver := NewMockFileSaver(nil)
+	crawler := webcrawler.NewWebCrawler(mockDownloader, parser.DefaultRegistry(), &pathmapper.FilePathMapper{NoHostDirectories: true}, mockSaver,
+		webcrawler.WebCrawlerSettings{MaxDepth: 2, MaxWorkers: 2, PageRequisites: true})
+
+	if _, err := crawler.Mirror(context.Background(), "https://example.com/"); err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+
+	// иначе при -nH файл CDN затёр бы одноимённый файл сайта
+	saved := mockSaver.GetSaved()
+	if string(saved["style.css"]) != "body { color: red }" {
+		t.Errorf("Expected the site stylesheet in style.css, got %q", saved["style.css"])
+	}
+	if string(saved["cdn.example.net/style.css"]) != "body { color: blue }" {
+		t.Errorf("Expected the CDN stylesheet under its host directory, got %q", saved["cdn.example.net/style.css"])
+	}
+}
@@ -0,0 +1,219 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"wget/downloader"
+	"wget/parser"
+	"wget/pathmapper"
+	"wget/storage"
+	"wget/webcrawler"
+)
+
+// abortingWriter обрывает соединение после limit байт тела
+type abortingWriter struct {
+	http.ResponseWriter
+	limit int
+}
+
+func (w *abortingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		_, _ = w.ResponseWriter.Write(p[:w.limit])
+		w.ResponseWriter.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}
+	w.limit -= len(p)
+	return w.ResponseWriter.Write(p)
+}
+
+// rangeServer отдаёт документ через http.ServeContent, поддерживающий Range и
+// If-Range, и обрывает первые failures ответов после cutAfter байт
+type rangeServer struct {
+	mu           sync.Mutex
+	content      string
+	etag         string
+	failures     int
+	cutAfter     int
+	ignoreRanges bool
+	changeAfter  int // после стольких запросов документ меняется, 0 — никогда
+	ranges       []string
+}
+
+func (s *rangeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.ranges = append(s.ranges, r.Header.Get("Range"))
+	content, etag := s.content, s.etag
+	if s.changeAfter > 0 && len(s.ranges) > s.changeAfter {
+		content, etag = strings.ToUpper(content), etag+"-changed"
+	}
+	fail := s.failures > 0
+	if fail {
+		s.failures--
+	}
+	s.mu.Unlock()
+
+	if s.ignoreRanges {
+		r.Header.Del("Range")
+	}
+	if fail {
+		w = &abortingWriter{ResponseWriter: w, limit: s.cutAfter}
+	}
+	w.Header().Set("ETag", etag)
+	http.ServeContent(w, r, "file.bin", time.Time{}, strings.NewReader(content))
+}
+
+func downloadThroughRetries(server *httptest.Server, maxAttempts int) (string, error) {
+	d := downloader.NewRetryingDownloader(&downloader.HTTPDownloader{}, downloader.RetrySettings{MaxAttempts: maxAttempts})
+	response, err := d.Download(context.Background(), server.URL)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(response.Body)
+	return string(body), err
+}
+
+func TestRetryingDownloader_Download_ResumesBrokenBody(t *testing.T) {
+	content := strings.Repeat("0123456789", 1000)
+	handler := &rangeServer{content: content, etag: `"v1"`, failures: 2, cutAfter: 3000}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	body, err := downloadThroughRetries(server, 3)
+
+	if err != nil {
+		t.Fatalf("Download returned an error: %v", err)
+	}
+	if body != content {
+		t.Errorf("Expected the complete document, got %d bytes", len(body))
+	}
+	expected := []string{"", "bytes=3000-", "bytes=6000-"}
+	if fmt.Sprint(handler.ranges) != fmt.Sprint(expected) {
+		t.Errorf("Expected Range headers %q, got %q", expected, handler.ranges)
+	}
+}
+
+func TestRetryingDownloader_Download_RestartsWhenRangesAreIgnored(t *testing.T) {
+	content := strings.Repeat("0123456789", 1000)
+	handler := &rangeServer{content: content, etag: `"v1"`, failures: 1, cutAfter: 3000, ignoreRanges: true}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	body, err := downloadThroughRetries(server, 2)
+
+	if err != nil {
+		t.Fatalf("Download returned an error: %v", err)
+	}
+	if body != content {
+		t.Errorf("Expected the complete document without duplicates, got %d bytes", len(body))
+	}
+}
+
+func TestRetryingDownloader_Download_DoesNotMixVersions(t *testing.T) {
+	handler := &rangeServer{content: strings.Repeat("abc", 3000), etag: `"v1"`, failures: 1, cutAfter: 3000, changeAfter: 1}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	_, err := downloadThroughRetries(server, 3)
+
+	if !errors.Is(err, downloader.ErrChanged) {
+		t.Errorf("Expected ErrChanged, got %v", err)
+	}
+}
+
+func TestHTTPDownloader_Download_RejectsUnexpectedContentRange(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Range", "bytes 0-9/10")
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	d := &downloader.HTTPDownloader{}
+	ctx := downloader.WithRange(context.Background(), downloader.Range{Start: 5, IfRange: `"v1"`})
+
+	if _, err := d.Download(ctx, server.URL); err == nil {
+		t.Error("Expected an error for a range that starts elsewhere")
+	}
+}
+
+func TestWebCrawler_Mirror_ContinueResumesPartialFile(t *testing.T) {
+	// Подготовка
+	content := strings.Repeat("0123456789", 1000)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	var ranges []string
+	interrupted := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><a href="/big.bin">big</a></html>`))
+			return
+		}
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		interrupt := !interrupted
+		interrupted = true
+		mu.Unlock()
+
+		w.Header().Set("ETag", `"big-v1"`)
+		if interrupt {
+			// Первая загрузка прерывается пользователем на середине
+			w.Header().Set("Content-Length", fmt.Sprint(len(content)))
+			_, _ = w.Write([]byte(content[:3000]))
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+			cancel()
+			<-r.Context().Done()
+			return
+		}
+		http.ServeContent(w, r, "big.bin", time.Time{}, strings.NewReader(content))
+	}))
+	defer server.Close()
+
+	output := t.TempDir()
+	mirror := func(ctx context.Context, resume bool) error {
+		crawler := webcrawler.NewWebCrawler(
+			&downloader.HTTPDownloader{},
+			parser.DefaultRegistry(),
+			&pathmapper.FilePathMapper{NoHostDirectories: true},
+			&storage.OsFileSaver{OutputDir: output},
+			webcrawler.WebCrawlerSettings{MaxDepth: 2, MaxWorkers: 1, StateFile: "state.json", Continue: resume},
+		)
+		_, err := crawler.Mirror(ctx, server.URL+"/")
+		return err
+	}
+
+	// Вызов
+	if err := mirror(ctx, false); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the first run to be interrupted, got %v", err)
+	}
+	if err := mirror(context.Background(), true); err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+
+	// Проверки
+	expected := []string{"", "bytes=3000-"}
+	if fmt.Sprint(ranges) != fmt.Sprint(expected) {
+		t.Errorf("Expected Range headers %q, got %q", expected, ranges)
+	}
+	data, err := os.ReadFile(filepath.Join(output, "big.bin"))
+	if err != nil || string(data) != content {
+		t.Errorf("Expected the complete file, got %d bytes and %v", len(data), err)
+	}
+	if _, err := os.Stat(filepath.Join(output, "big.bin.part")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the partial file to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"wget/downloader"
+)
+
+// MockFlakyDownloader — возвращает ошибки из списка, затем успешный ответ
+type MockFlakyDownloader struct {
+	mu     sync.Mutex
+	errs   []error
+	Calls  int
+	called []time.Time
+}
+
+func (m *MockFlakyDownloader) Download(ctx context.Context, url string) (*downloader.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Calls++
+	m.called = append(m.called, time.Now())
+	if len(m.errs) > 0 {
+		err := m.errs[0]
+		m.errs = m.errs[1:]
+		return nil, err
+	}
+	return &downloader.Response{URL: url, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+}
+
+func TestRetryingDownloader_Download_RetriesTransientErrors(t *testing.T) {
+	mock := &MockFlakyDownloader{errs: []error{
+		&downloader.DownloadError{URL: "u", StatusCode: http.StatusServiceUnavailable},
+		&net.OpError{Op: "dial", Err: errors.New("connection refused")},
+		&downloader.DownloadError{URL: "u", StatusCode: http.StatusTooManyRequests},
+	}}
+	d := downloader.NewRetryingDownloader(mock, downloader.RetrySettings{MaxAttempts: 5, BaseDelay: time.Millisecond})
+
+	response, err := d.Download(context.Background(), "https://example.com")
+
+	if err != nil {
+		t.Fatalf("Download returned an error: %v", err)
+	}
+	if body := readBody(t, response); body != "ok" {
+		t.Errorf("Unexpected response %q", body)
+	}
+	if mock.Calls != 4 {
+		t.Errorf("Expected 4 attempts, got %d", mock.Calls)
+	}
+}
+
+func TestRetryingDownloader_Download_StopsAfterMaxAttempts(t *testing.T) {
+	lastErr := &downloader.DownloadError{URL: "u", StatusCode: http.StatusBadGateway}
+	mock := &MockFlakyDownloader{errs: []error{lastErr, lastErr, lastErr, lastErr}}
+	d := downloader.NewRetryingDownloader(mock, downloader.RetrySettings{MaxAttempts: 3, BaseDelay: time.Millisecond})
+
+	_, err := d.Download(context.Background(), "https://example.com")
+
+	if !errors.Is(err, lastErr) {
+		t.Fatalf("Expected the last error, got: %v", err)
+	}
+	if mock.Calls != 3 {
+		t.Errorf("Expected 3 attempts, got %d", mock.Calls)
+	}
+}
+
+func TestRetryingDownloader_Download_DoesNotRetryClientErrors(t *testing.T) {
+	mock := &MockFlakyDownloader{errs: []error{&downloader.DownloadError{URL: "u", StatusCode: http.StatusNotFound}}}
+	d := downloader.NewRetryingDownloader(mock, downloader.RetrySettings{MaxAttempts: 5, BaseDelay: time.Millisecond})
+
+	_, err := d.Download(context.Background(), "https://example.com")
+
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if mock.Calls != 1 {
+		t.Errorf("Expected 1 attempt, got %d", mock.Calls)
+	}
+}
+
+func TestRetryingDownloader_Download_HonorsRetryAfter(t *testing.T) {
+	mock := &MockFlakyDownloader{errs: []error{
+		&downloader.DownloadError{URL: "u", StatusCode: http.StatusTooManyRequests, RetryAfter: 100 * time.Millisecond},
+	}}
+	d := downloader.NewRetryingDownloader(mock, downloader.RetrySettings{MaxAttempts: 2, BaseDelay: time.Millisecond, MaxDelay: time.Second})
+
+	if _, err := d.Download(context.Background(), "https://example.com"); err != nil {
+		t.Fatalf("Download returned an error: %v", err)
+	}
+
+	if waited := mock.called[1].Sub(mock.called[0]); waited < 100*time.Millisecond {
+		t.Errorf("Expected to wait at least 100ms as requested by Retry-After, waited %s", waited)
+	}
+}
+
+func TestRetryingDownloader_Download_GivesUpOnLongRetryAfter(t *testing.T) {
+	mock := &MockFlakyDownloader{errs: []error{
+		&downloader.DownloadError{URL: "u", StatusCode: http.StatusServiceUnavailable, RetryAfter: 120 * time.Second},
+	}}
+	d := downloader.NewRetryingDownloader(mock, downloader.RetrySettings{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 30 * time.Second})
+
+	start := time.Now()
+	_, err := d.Download(context.Background(), "https://example.com")
+
+	// повтор раньше срока, указанного сервером, бесполезен
+	var downloadErr *downloader.DownloadError
+	if !errors.As(err, &downloadErr) || downloadErr.RetryAfter != 120*time.Second {
+		t.Fatalf("Expected the 503 with its Retry-After, got: %v", err)
+	}
+	if mock.Calls != 1 || time.Since(start) > time.Second {
+		t.Errorf("Expected no retry before the requested 120s, got %d attempts", mock.Calls)
+	}
+}
+
+func TestRetryingDownloader_Download_StopsOnCanceledContext(t *testing.T) {
+	mock := &MockFlakyDownloader{errs: []error{&downloader.DownloadError{URL: "u", StatusCode: http.StatusServiceUnavailable}}}
+	d := downloader.NewRetryingDownloader(mock, downloader.RetrySettings{MaxAttempts: 5, BaseDelay: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := d.Download(ctx, "https://example.com")
+
+	if err == nil {
+		t.Fatalf("Expected an error")
+	}
+	if mock.Calls != 1 {
+		t.Errorf("Expected 1 attempt, got %d", mock.Calls)
+	}
+}
+
+func TestIsRetryable_ClassifiesErrors(t *testing.T) {
+	cases := []struct {
+		err       error
+		retryable bool
+	}{
+		{&downloader.DownloadError{StatusCode: http.StatusRequestTimeout}, true},
+		{&downloader.DownloadError{StatusCode: http.StatusTooManyRequests}, true},
+		{&downloader.DownloadError{StatusCode: http.StatusInternalServerError}, true},
+		{&downloader.DownloadError{StatusCode: http.StatusForbidden}, false},
+		{&downloader.DownloadError{StatusCode: http.StatusNotFound}, false},
+		{&net.DNSError{Err: "timeout", IsTimeout: true}, true},
+		{io.ErrUnexpectedEOF, true},
+		{downloader.ErrReadTimeout, true},
+		{context.Canceled, false},
+		{errors.New("unsupported protocol scheme"), false},
+	}
+
+	for _, c := range cases {
+		if got := downloader.IsRetryable(c.err); got != c.retryable {
+			t.Errorf("IsRetryable(%v) = %v, expected %v", c.err, got, c.retryable)
+		}
+	}
+}
+
+func TestHTTPDownloader_Download_ReportsRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "2")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := (&downloader.HTTPDownloader{}).Download(context.Background(), server.URL)
+
+	var downloadErr *downloader.DownloadError
+	if !errors.As(err, &downloadErr) || downloadErr.RetryAfter != 2*time.Second {
+		t.Fatalf("Expected DownloadError with RetryAfter 2s, got: %v", err)
+	}
+}
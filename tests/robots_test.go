@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+	"wget/downloader"
+	"wget/robots"
+)
+
+func assertAllowed(t *testing.T, group *robots.Group, cases map[string]bool) {
+	t.Helper()
+	for path, expected := range cases {
+		if got := group.Allowed(path); got != expected {
+			t.Errorf("Allowed(%s) = %v, expected %v", path, got, expected)
+		}
+	}
+}
+
+func TestRobots_Allowed_LongestMatchWins(t *testing.T) {
+	rules := robots.Parse([]byte(`
+User-agent: *
+Disallow: /private
+Allow: /private/public
+Disallow: /page
+Allow: /page
+`))
+
+	assertAllowed(t, rules.Agent("wget-go/1.0"), map[string]bool{
+		"/":                     true,
+		"/private":              false,
+		"/private/secret":       false,
+		"/private/public/index": true,
+		"/page":                 true, // при равной длине побеждает Allow
+		"/robots.txt":           true,
+	})
+}
+
+func TestRobots_Allowed_Wildcards(t *testing.T) {
+	rules := robots.Parse([]byte(`
+User-agent: *
+Disallow: /*.pdf$
+Disallow: /search*q=
+Disallow: /exact$
+`))
+
+	assertAllowed(t, rules.Agent("wget-go"), map[string]bool{
+		"/docs/file.pdf":     false,
+		"/docs/file.pdf?x=1": true,
+		"/search?q=go":       false,
+		"/search?page=2":     true,
+		"/exact":             false,
+		"/exact/more":        true,
+	})
+}
+
+func TestRobots_Agent_SelectsMostSpecificGroup(t *testing.T) {
+	rules := robots.Parse([]byte(`
+# Общие правила
+User-agent: *
+Disallow: /
+
+User-agent: Wget-Go
+User-agent: other
+Disallow: /admin   # комментарий
+Crawl-delay: 1.5
+`))
+
+	group := rules.Agent("wget-go/1.0")
+	assertAllowed(t, group, map[string]bool{
+		"/":      true,
+		"/admin": false,
+	})
+	if group.CrawlDelay != 1500*time.Millisecond {
+		t.Errorf("Expected Crawl-delay 1.5s, got %v", group.CrawlDelay)
+	}
+
+	assertAllowed(t, rules.Agent("curl/8.0"), map[string]bool{
+		"/page": false,
+	})
+}
+
+func TestRobots_Parse_CollectsSitemaps(t *testing.T) {
+	rules := robots.Parse([]byte(`
+Sitemap: https://example.com/sitemap.xml
+User-agent: *
+Disallow: /private
+Sitemap: https://example.com/news.xml
+`))
+
+	expected := []string{"https://example.com/sitemap.xml", "https://example.com/news.xml"}
+	if !slices.Equal(rules.Sitemaps, expected) {
+		t.Errorf("Expected sitemaps %v, got %v", expected, rules.Sitemaps)
+	}
+	// Строки Sitemap не разрывают группу
+	assertAllowed(t, rules.Agent("wget-go"), map[string]bool{
+		"/private": false,
+	})
+}
+
+func robotsChecker(d downloader.Downloader) *robots.Checker {
+	return robots.NewChecker(d, "wget-go/1.0")
+}
+
+func TestRobots_Checker_HandlesMissingAndUnreachableRobotsTxt(t *testing.T) {
+	mockDownloader := &MockDownloaderWithSomeErrors{
+		responses: map[string][]byte{
+			"https://example.com/robots.txt": []byte("User-agent: *\nDisallow: /private\n"),
+		},
+		errors: map[string]error{
+			"https://missing.com/robots.txt": &downloader.DownloadError{URL: "https://missing.com/robots.txt", StatusCode: 404},
+			"https://broken.com/robots.txt":  &downloader.DownloadError{URL: "https://broken.com/robots.txt", StatusCode: 503},
+			"https://offline.com/robots.txt": errors.New("connection refused"),
+		},
+	}
+	checker := robots.NewChecker(mockDownloader, "wget-go/1.0")
+	ctx := context.Background()
+
+	cases := map[string]bool{
+		"https://example.com/page":      true,
+		"https://example.com/private/x": false,
+		"https://missing.com/page":      true,
+		"https://broken.com/page":       false,
+		"https://offline.com/page":      false,
+		"mailto:user@example.com":       true,
+	}
+	for rawUrl, expected := range cases {
+		if got := checker.Allowed(ctx, rawUrl); got != expected {
+			t.Errorf("Allowed(%s) = %v, expected %v", rawUrl, got, expected)
+		}
+	}
+
+	// robots.txt скачивается один раз на хост
+	checker.Allowed(ctx, "https://example.com/other")
+	count := 0
+	for _, u := range mockDownloader.CallLog {
+		if u == "https://example.com/robots.txt" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("Expected robots.txt to be downloaded once, got %d", count)
+	}
+}
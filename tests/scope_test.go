@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"net/url"
+	"testing"
+	"wget/webcrawler"
+)
+
+func assertScope(t *testing.T, scope webcrawler.Scope, seed string, cases map[string]bool) {
+	t.Helper()
+	seedUrl, _ := url.Parse(seed)
+	for target, expected := range cases {
+		targetUrl, _ := url.Parse(target)
+		if got := scope.Contains(seedUrl, targetUrl); got != expected {
+			t.Errorf("Contains(%s, %s) = %v, expected %v", seed, target, got, expected)
+		}
+	}
+}
+
+func TestScope_Contains_SameHost(t *testing.T) {
+	assertScope(t, webcrawler.Scope{}, "https://example.com", map[string]bool{
+		"https://example.com/page":         true,
+		"http://example.com/page":          true,
+		"https://EXAMPLE.com/page":         true,
+		"https://example.com:443/page":     true,
+		"https://www.example.com/page":     true,
+		"https://example.com.evil.org/":    false,
+		"https://docs.example.com/":        false,
+		"https://example.com:8443/":        false,
+		"ftp://example.com/file":           false,
+		"https://external.com/example.com": false,
+	})
+}
+
+func TestScope_Contains_SameDomain(t *testing.T) {
+	assertScope(t, webcrawler.Scope{Policy: webcrawler.SameDomain}, "https://www.example.co.uk/", map[string]bool{
+		"https://docs.example.co.uk/": true,
+		"https://example.co.uk/":      true,
+		"https://other.co.uk/":        false,
+		"https://example.co.uk.evil/": false,
+	})
+}
+
+func TestScope_Contains_NoParent(t *testing.T) {
+	scope := webcrawler.Scope{NoParent: true}
+
+	assertScope(t, scope, "https://example.com/docs", map[string]bool{
+		"https://example.com/docs":       true,
+		"https://example.com/docs/":      true,
+		"https://example.com/docs/guide": true,
+		"https://example.com/docs-old/":  false,
+		"https://example.com/":           false,
+	})
+	assertScope(t, scope, "https://example.com/docs/index.html", map[string]bool{
+		"https://example.com/docs/guide": true,
+		"https://example.com/blog/":      false,
+	})
+}
+
+func TestScope_Contains_HostLists(t *testing.T) {
+	scope := webcrawler.Scope{
+		SpanHosts:  true,
+		AllowHosts: []string{"cdn.net", "partner.org"},
+		DenyHosts:  []string{"ads.cdn.net"},
+	}
+
+	assertScope(t, scope, "https://example.com", map[string]bool{
+		"https://example.com/":    true,
+		"https://static.cdn.net/": true,
+		"https://partner.org/":    true,
+		"https://ads.cdn.net/":    false,
+		"https://unrelated.com/":  false,
+	})
+
+	assertScope(t, webcrawler.Scope{SpanHosts: true}, "https://example.com", map[string]bool{
+		"https://unrelated.com/": true,
+	})
+	assertScope(t, webcrawler.Scope{DenyHosts: []string{"example.com"}}, "https://example.com", map[string]bool{
+		"https://example.com/": false,
+	})
+}
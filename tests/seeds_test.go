@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"wget/webcrawler"
+)
+
+func TestReadSeeds(t *testing.T) {
+	input := `# Документация вендоров
+https://docs.example.com/
+
+  https://api.example.org/v2/  
+# https://disabled.example.net/
+`
+
+	seeds, err := webcrawler.ReadSeeds(strings.NewReader(input))
+
+	if err != nil {
+		t.Fatalf("ReadSeeds returned an error: %v", err)
+	}
+	expected := []string{"https://docs.example.com/", "https://api.example.org/v2/"}
+	if !slices.Equal(seeds, expected) {
+		t.Errorf("Expected %v, got %v", expected, seeds)
+	}
+}
+
+func TestSeedsFromHTML(t *testing.T) {
+	html := `<html><body>
+<a href="https://docs.example.com/">Docs</a>
+<a href="/guide/">Guide</a>
+<a href="mailto:team@example.com">Mail</a>
+<img src="/logo.png">
+</body></html>`
+
+	seeds, err := webcrawler.SeedsFromHTML([]byte(html), "https://example.com/list.html")
+
+	if err != nil {
+		t.Fatalf("SeedsFromHTML returned an error: %v", err)
+	}
+	expected := []string{"https://docs.example.com/", "https://example.com/guide/"}
+	if !slices.Equal(seeds, expected) {
+		t.Errorf("Expected %v, got %v", expected, seeds)
+	}
+
+	// Без базового URL относительные ссылки пропускаются
+	seeds, _ = webcrawler.SeedsFromHTML([]byte(html), "")
+	if !slices.Equal(seeds, []string{"https://docs.example.com/"}) {
+		t.Errorf("Expected only the absolute link, got %v", seeds)
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"testing"
+	"time"
+	"wget/sitemap"
+)
+
+func gzipData(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestSitemap_Parse_URLSet(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc> https://example.com/a </loc><lastmod>2024-05-01</lastmod></url>
+  <url><loc>https://example.com/b</loc><lastmod>2024-05-02T10:30:00+02:00</lastmod></url>
+  <url><loc>https://example.com/c</loc><lastmod>yesterday</lastmod></url>
+  <url><lastmod>2024-05-03</lastmod></url>
+</urlset>`
+
+	result, err := sitemap.Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse returned an error: %v", err)
+	}
+
+	expected := []sitemap.Page{
+		{URL: "https://example.com/a", LastMod: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{URL: "https://example.com/b", LastMod: time.Date(2024, 5, 2, 8, 30, 0, 0, time.UTC)},
+		{URL: "https://example.com/c"}, // некорректный lastmod игнорируется
+	}
+	if len(result.Pages) != len(expected) {
+		t.Fatalf("Expected %d pages, got %v", len(expected), result.Pages)
+	}
+	for i, page := range result.Pages {
+		if page.URL != expected[i].URL || !page.LastMod.Equal(expected[i].LastMod) {
+			t.Errorf("Page %d: expected %v, got %v", i, expected[i], page)
+		}
+	}
+}
+
+func TestSitemap_Parse_IndexGzipAndText(t *testing.T) {
+	index := `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/pages.xml.gz</loc></sitemap>
+</sitemapindex>`
+
+	result, err := sitemap.Parse(gzipData(t, index))
+	if err != nil {
+		t.Fatalf("Parse returned an error: %v", err)
+	}
+	if len(result.Sitemaps) != 1 || result.Sitemaps[0].URL != "https://example.com/pages.xml.gz" || len(result.Pages) != 0 {
+		t.Errorf("Unexpected sitemap index: %+v", result)
+	}
+
+	result, err = sitemap.Parse([]byte("https://example.com/a\n\nhttps://example.com/b\n"))
+	if err != nil {
+		t.Fatalf("Parse returned an error: %v", err)
+	}
+	if len(result.Pages) != 2 || result.Pages[1].URL != "https://example.com/b" {
+		t.Errorf("Unexpected text sitemap: %+v", result)
+	}
+
+	if _, err := sitemap.Parse([]byte("<html><body>Not found</body></html>")); err == nil {
+		t.Error("Expected an error for a document that is not a sitemap")
+	}
+}
+
+func TestSitemap_Collector_FollowsRobotsAndIndexes(t *testing.T) {
+	mockDownloader := NewMockDownloader(map[string][]byte{
+		"https://example.com/robots.txt": []byte("Sitemap: https://example.com/index.xml\nSitemap: https://example.com/broken.xml\n"),
+		"https://example.com/index.xml": []byte(`<sitemapindex>
+  <sitemap><loc>https://example.com/pages.xml.gz</loc></sitemap>
+  <sitemap><loc>/index.xml</loc></sitemap>
+</sitemapindex>`),
+		"https://example.com/pages.xml.gz": gzipData(t, `<urlset><url><loc>/hidden</loc></url></urlset>`),
+	}, nil)
+
+	collector := sitemap.NewCollector(mockDownloader, robotsChecker(mockDownloader))
+	pages, err := collector.Pages(context.Background(), "https://example.com/")
+
+	// Ошибка одного sitemap не мешает вернуть страницы остальных
+	if err == nil {
+		t.Error("Expected an error for the missing sitemap")
+	}
+	if len(pages) != 1 || pages[0].URL != "https://example.com/hidden" {
+		t.Errorf("Expected the page from the nested sitemap, got %v", pages)
+	}
+	if mockDownloader.WasCalledWith("https://example.com/sitemap.xml") {
+		t.Error("Expected /sitemap.xml not to be guessed when robots.txt lists sitemaps")
+	}
+}
+
+func TestSitemap_Collector_GuessesSitemapLocation(t *testing.T) {
+	mockDownloader := NewMockDownloader(map[string][]byte{}, nil)
+
+	pages, err := sitemap.NewCollector(mockDownloader, nil).Pages(context.Background(), "https://example.com/docs/")
+
+	// Отсутствие sitemap.xml по умолчанию не считается ошибкой
+	if err != nil || len(pages) != 0 {
+		t.Errorf("Expected no pages and no error, got %v and %v", pages, err)
+	}
+	if !mockDownloader.WasCalledWith("https://example.com/sitemap.xml") {
+		t.Error("Expected /sitemap.xml to be tried")
+	}
+}
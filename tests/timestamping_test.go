@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"wget/downloader"
+	"wget/parser"
+	"wget/pathmapper"
+	"wget/storage"
+	"wget/webcrawler"
+)
+
+// timestampingServer отдаёт документы через http.ServeContent, который сам
+// отвечает 304 на условные запросы, и считает полные ответы
+type timestampingServer struct {
+	mu       sync.Mutex
+	full     map[string]int
+	modTime  time.Time
+	contents map[string]string
+}
+
+func (s *timestampingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	content, ok := s.contents[r.URL.Path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Header.Get("If-Modified-Since") == "" && r.Header.Get("If-None-Match") == "" {
+		s.mu.Lock()
+		s.full[r.URL.Path]++
+		s.mu.Unlock()
+	}
+	if r.URL.Path == "/" {
+		w.Header().Set("ETag", `"home-v1"`)
+		w.Header().Set("Content-Type", "text/html")
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(content))
+		return
+	}
+	http.ServeContent(w, r, r.URL.Path, s.modTime, strings.NewReader(content))
+}
+
+func TestWebCrawler_Mirror_TimestampingSkipsUnchangedDocuments(t *testing.T) {
+	// Подготовка
+	handler := &timestampingServer{
+		full:    map[string]int{},
+		modTime: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+		contents: map[string]string{
+			"/":          `<html><a href="/page.html">page</a><img src="/logo.png"></html>`,
+			"/page.html": `<html><a href="/deep.html">deep</a></html>`,
+			"/deep.html": `<html>deep</html>`,
+			"/logo.png":  "png",
+		},
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	output := t.TempDir()
+	mirror := func() *webcrawler.WebCrawlerResult {
+		crawler := webcrawler.NewWebCrawler(
+			&downloader.HTTPDownloader{},
+			parser.DefaultRegistry(),
+			&pathmapper.FilePathMapper{NoHostDirectories: true},
+			&storage.OsFileSaver{OutputDir: output},
+			webcrawler.WebCrawlerSettings{MaxDepth: 3, MaxWorkers: 2, Timestamping: true, StateFile: "state.json"},
+		)
+		result, err := crawler.Mirror(context.Background(), server.URL+"/")
+		if err != nil {
+			t.Fatalf("Mirror returned an error: %v", err)
+		}
+		return result
+	}
+
+	// Вызов: первый запуск скачивает всё, второй только проверяет
+	mirror()
+	result := mirror()
+
+	// Проверки
+	if result.CountSuccess != 4 || result.CountError != 0 {
+		t.Errorf("Expected 4 successes and 0 errors, got %d and %d", result.CountSuccess, result.CountError)
+	}
+	for path, count := range handler.full {
+		if count != 1 {
+			t.Errorf("Expected %s to be downloaded in full once, got %d", path, count)
+		}
+	}
+	// Ссылки неизменившихся страниц всё равно обходятся
+	if len(handler.full) != 4 {
+		t.Errorf("Expected 4 documents, got %v", handler.full)
+	}
+
+	info, err := os.Stat(filepath.Join(output, "logo.png"))
+	if err != nil {
+		t.Fatalf("Expected logo.png to be saved: %v", err)
+	}
+	if !info.ModTime().Equal(handler.modTime) {
+		t.Errorf("Expected mtime %v from Last-Modified, got %v", handler.modTime, info.ModTime())
+	}
+
+	// Удалённая локальная копия скачивается заново
+	if err := os.Remove(filepath.Join(output, "logo.png")); err != nil {
+		t.Fatal(err)
+	}
+	mirror()
+	if handler.full["/logo.png"] != 2 {
+		t.Errorf("Expected the deleted logo.png to be downloaded again, got %d full downloads", handler.full["/logo.png"])
+	}
+}
+
+func TestWebCrawler_Mirror_UnrequestedNotModifiedIsAnError(t *testing.T) {
+	// Подготовка: сервер (или прокси) всегда отвечает 304
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer server.Close()
+
+	for _, timestamping := range []bool{false, true} {
+		output := t.TempDir()
+		crawler := webcrawler.NewWebCrawler(
+			&downloader.HTTPDownloader{},
+			parser.DefaultRegistry(),
+			&pathmapper.FilePathMapper{NoHostDirectories: true},
+			&storage.OsFileSaver{OutputDir: output},
+			webcrawler.WebCrawlerSettings{MaxDepth: 2, MaxWorkers: 1, Timestamping: timestamping, StateFile: "state.json"},
+		)
+
+		// Вызов
+		result, err := crawler.Mirror(context.Background(), server.URL+"/")
+
+		// Проверки: без сохранённой копии 304 — ошибка загрузки, а не пустой файл
+		if err != nil {
+			t.Fatalf("Mirror returned an error: %v", err)
+		}
+		if result.CountSuccess != 0 || result.CountError != 1 {
+			t.Errorf("Timestamping %v: expected 1 error, got %+v", timestamping, result)
+		}
+		if _, err := os.Stat(filepath.Join(output, "index.html")); !os.IsNotExist(err) {
+			t.Errorf("Timestamping %v: expected no saved file, got %v", timestamping, err)
+		}
+	}
+}
+
+func TestWebCrawler_Mirror_NoDirectoriesKeepsFilesAcrossRuns(t *testing.T) {
+	// Подготовка
+	mockDownloader := NewMockDownloader(map[string][]byte{
+		"https://example.com/":      []byte(`<a href="/docs/">Docs</a>`),
+		"https://example.com/docs/": []byte(`<p>docs</p>`),
+	}, nil)
+	mockSaver := NewMockFileSaver(nil)
+	mirror := func(seed string) {
+		t.Helper()
+		crawler := webcrawler.NewWebCrawler(mockDownloader, parser.DefaultRegistry(), &pathmapper.FilePathMapper{NoDirectories: true}, mockSaver,
+			webcrawler.WebCrawlerSettings{MaxDepth: 2, MaxWorkers: 1, StateFile: "state.json", Timestamping: true})
+		if _, err := crawler.Mirror(context.Background(), seed); err != nil {
+			t.Fatalf("Mirror returned an error: %v", err)
+		}
+	}
+
+	// Вызов: второй запуск начинает со страницы, получившей номер
+	mirror("https://example.com/")
+	mirror("https://example.com/docs/")
+
+	// Проверка
+	saved := mockSaver.GetSaved()
+	if string(saved["index.html"]) != `<a href="/docs/">Docs</a>` {
+		t.Errorf("Expected the home page to keep index.html, got %q", saved["index.html"])
+	}
+	if string(saved["index.html.1"]) != "<p>docs</p>" {
+		t.Errorf("Expected the docs to keep index.html.1, got %q", saved["index.html.1"])
+	}
+}
@@ -0,0 +1,130 @@
+package webcrawler
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// DefaultTrackingParams are query parameters that only identify where a
+// visitor came from and never change the page.
+var DefaultTrackingParams = []string{"utm_*", "fbclid", "gclid", "msclkid", "mc_cid", "mc_eid"}
+
+// Canonicalizer reduces the spellings of a URL to one form used to detect
+// duplicates. It always lowercases the scheme and host, drops default ports,
+// replaces an empty path with "/" and normalizes percent-encoding.
+type Canonicalizer struct {
+	KeepFragment bool
+	SortQuery    bool
+	// StripParams lists query parameters to remove. A trailing "*" matches
+	// any parameter with that prefix, as in "utm_*".
+	StripParams []string
+}
+
+// Canonicalize returns the canonical form of rawUrl, or rawUrl itself if it
+// cannot be parsed.
+func (c Canonicalizer) Canonicalize(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil || !u.IsAbs() {
+		return rawUrl
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); u.Scheme == "http" && port == "80" || u.Scheme == "https" && port == "443" {
+		u.Host = strings.TrimSuffix(u.Host, ":"+port)
+	}
+
+	if u.Opaque == "" {
+		rawPath := normalizeEscapes(u.EscapedPath())
+		if rawPath == "" {
+			rawPath = "/"
+		}
+		if path, err := url.PathUnescape(rawPath); err == nil {
+			u.Path, u.RawPath = path, rawPath
+		}
+	}
+
+	u.RawQuery = c.canonicalQuery(u.RawQuery)
+	u.ForceQuery = false
+	if !c.KeepFragment {
+		u.Fragment, u.RawFragment = "", ""
+	}
+	return u.String()
+}
+
+func (c Canonicalizer) canonicalQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	params := make([]string, 0)
+	for _, param := range strings.Split(rawQuery, "&") {
+		if param == "" {
+			continue
+		}
+		param = normalizeEscapes(param)
+		name, _, _ := strings.Cut(param, "=")
+		if decoded, err := url.QueryUnescape(name); err == nil {
+			name = decoded
+		}
+		if !c.stripped(name) {
+			params = append(params, param)
+		}
+	}
+	if c.SortQuery {
+		slices.Sort(params)
+	}
+	return strings.Join(params, "&")
+}
+
+func (c Canonicalizer) stripped(name string) bool {
+	for _, pattern := range c.StripParams {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(name, prefix) || name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeEscapes decodes percent-encoded unreserved characters and
+// uppercases the hex digits of the remaining escapes.
+func normalizeEscapes(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) || !isHex(s[i+1]) || !isHex(s[i+2]) {
+			b.WriteByte(s[i])
+			continue
+		}
+		decoded := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if isUnreserved(decoded) {
+			b.WriteByte(decoded)
+		} else {
+			b.WriteString(strings.ToUpper(s[i : i+3]))
+		}
+		i += 2
+	}
+	return b.String()
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
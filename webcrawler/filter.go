@@ -0,0 +1,164 @@
+package webcrawler
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Filter narrows down which URLs in scope are downloaded. Each check
+// returns the rule that rejects a URL, or "" when it passes.
+//
+// Name lists hold extensions such as "zip" or, when they contain *, ? or [,
+// glob patterns of the file name. Directory lists hold path prefixes such as
+// "/print", where a glob matches a whole directory, e.g. "/*/print". Type
+// lists hold MIME types such as "image/png" or "video/*".
+type Filter struct {
+	Accept             []string // file names to keep, empty keeps all
+	Reject             []string
+	IncludeDirectories []string // directories to crawl, empty crawls all
+	ExcludeDirectories []string
+	AcceptRegex        *regexp.Regexp // matched against the canonical URL
+	RejectRegex        *regexp.Regexp
+	AcceptTypes        []string // checked once the response headers arrive
+	RejectTypes        []string
+}
+
+// CheckURL applies the regex and directory rules.
+func (f Filter) CheckURL(rawUrl string) string {
+	if f.AcceptRegex != nil && !f.AcceptRegex.MatchString(rawUrl) {
+		return fmt.Sprintf("accept regex %q does not match", f.AcceptRegex)
+	}
+	if f.RejectRegex != nil && f.RejectRegex.MatchString(rawUrl) {
+		return fmt.Sprintf("reject regex %q matches", f.RejectRegex)
+	}
+	if len(f.IncludeDirectories) == 0 && len(f.ExcludeDirectories) == 0 {
+		return ""
+	}
+	dir := directoryOf(rawUrl)
+	if pattern, ok := matchDirectory(dir, f.ExcludeDirectories); ok {
+		return fmt.Sprintf("excluded directory %q", pattern)
+	}
+	if _, ok := matchDirectory(dir, f.IncludeDirectories); !ok && len(f.IncludeDirectories) > 0 {
+		return fmt.Sprintf("directory %q is not included", dir)
+	}
+	return ""
+}
+
+// CheckName applies the accept and reject lists to the file name.
+func (f Filter) CheckName(rawUrl string) string {
+	name := fileName(rawUrl)
+	if pattern, ok := matchName(name, f.Reject); ok {
+		return fmt.Sprintf("reject pattern %q", pattern)
+	}
+	if _, ok := matchName(name, f.Accept); !ok && len(f.Accept) > 0 {
+		return fmt.Sprintf("file name %q is not accepted", name)
+	}
+	return ""
+}
+
+// CheckType applies the MIME type lists to a Content-Type header.
+func (f Filter) CheckType(contentType string) string {
+	if len(f.AcceptTypes) == 0 && len(f.RejectTypes) == 0 {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	if pattern, ok := matchType(mediaType, f.RejectTypes); ok {
+		return fmt.Sprintf("reject type %q", pattern)
+	}
+	if _, ok := matchType(mediaType, f.AcceptTypes); !ok && len(f.AcceptTypes) > 0 {
+		return fmt.Sprintf("type %q is not accepted", mediaType)
+	}
+	return ""
+}
+
+// htmlExtensions are the file extensions of documents that may be HTML. A
+// page rejected by name is still crawled through when it has one of them.
+var htmlExtensions = map[string]bool{
+	"": true, ".html": true, ".htm": true, ".xhtml": true, ".shtml": true,
+	".php": true, ".asp": true, ".aspx": true, ".jsp": true, ".cgi": true,
+}
+
+func mayBeHTML(rawUrl string) bool {
+	return htmlExtensions[strings.ToLower(path.Ext(fileName(rawUrl)))]
+}
+
+func fileName(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Path == "" || strings.HasSuffix(u.Path, "/") {
+		return ""
+	}
+	return path.Base(u.Path)
+}
+
+// directoryOf returns the directory of the URL path without a trailing
+// slash, "/" for the root.
+func directoryOf(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil || u.Path == "" {
+		return "/"
+	}
+	if strings.HasSuffix(u.Path, "/") {
+		return path.Clean(u.Path)
+	}
+	return path.Dir(u.Path)
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+func matchName(name string, patterns []string) (string, bool) {
+	lower := strings.ToLower(name)
+	for _, pattern := range patterns {
+		if isGlob(pattern) {
+			if ok, _ := path.Match(strings.ToLower(pattern), lower); ok {
+				return pattern, true
+			}
+		} else if extension := strings.ToLower(strings.TrimPrefix(pattern, ".")); extension != "" &&
+			strings.HasSuffix(lower, "."+extension) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// matchDirectory reports whether dir is one of the directories or lies
+// below one of them.
+func matchDirectory(dir string, patterns []string) (string, bool) {
+	for _, pattern := range patterns {
+		clean := path.Clean("/" + pattern)
+		if !isGlob(clean) {
+			if dir == clean || clean == "/" || strings.HasPrefix(dir, clean+"/") {
+				return pattern, true
+			}
+			continue
+		}
+		// A glob is matched against dir and each of its ancestors.
+		for ancestor := dir; ; ancestor = path.Dir(ancestor) {
+			if ok, _ := path.Match(clean, ancestor); ok {
+				return pattern, true
+			}
+			if ancestor == "/" {
+				break
+			}
+		}
+	}
+	return "", false
+}
+
+func matchType(mediaType string, patterns []string) (string, bool) {
+	for _, pattern := range patterns {
+		p := strings.ToLower(strings.TrimSpace(pattern))
+		if p == mediaType || strings.HasSuffix(p, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(p, "*")) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,78 @@
+package webcrawler
+
+import "sync"
+
+type task struct {
+	url      string
+	depth    int
+	page     bool
+	embedded bool // a document embedded into a page, such as an iframe
+	seed     int  // index of the seed whose scope applies to links found
+}
+
+// frontier is a FIFO work queue shared by crawl workers. It tracks tasks
+// that are being processed, so pop reports exhaustion only when no worker
+// can produce more work, and snapshot can include unfinished tasks.
+type frontier struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  []task
+	active map[string]task
+	closed bool
+}
+
+func newFrontier() *frontier {
+	f := &frontier{active: map[string]task{}}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+func (f *frontier) push(t task) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queue = append(f.queue, t)
+	f.cond.Signal()
+}
+
+func (f *frontier) pop() (task, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.queue) == 0 && len(f.active) > 0 && !f.closed {
+		f.cond.Wait()
+	}
+	if f.closed || len(f.queue) == 0 {
+		return task{}, false
+	}
+	t := f.queue[0]
+	f.queue[0] = task{}
+	f.queue = f.queue[1:]
+	f.active[t.url] = t
+	return t, true
+}
+
+func (f *frontier) done(t task) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.active, t.url)
+	if len(f.active) == 0 {
+		f.cond.Broadcast()
+	}
+}
+
+func (f *frontier) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	f.cond.Broadcast()
+}
+
+// snapshot returns unfinished tasks followed by queued ones.
+func (f *frontier) snapshot() []task {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tasks := make([]task, 0, len(f.active)+len(f.queue))
+	for _, t := range f.active {
+		tasks = append(tasks, t)
+	}
+	return append(tasks, f.queue...)
+}
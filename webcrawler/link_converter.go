@@ -0,0 +1,136 @@
+package webcrawler
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"wget/parser"
+
+	"golang.org/x/net/html"
+)
+
+// convertPages rewrites links in every saved document once the crawl is
+// over, when the full set of local copies is known. Documents converted by
+// an earlier run are left alone, as their relative links would otherwise be
+// resolved against their URL a second time.
+func (c *WebCrawler) convertPages(state *crawl) error {
+	local := c.localPaths(state)
+	var errs []error
+	for _, o := range state.outcomes {
+		p, ok := c.Parsers.Lookup(o.ContentType)
+		if o.Path == "" || o.Converted || !ok {
+			continue
+		}
+		data, err := c.FileSaver.Load(o.Path)
+		if err == nil {
+			data, err = c.convertLinks(p, data, o.FinalURL, o.Path, local)
+		}
+		if err == nil {
+			err = c.FileSaver.Save(o.Path, bytes.NewReader(data))
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("convert links in %s: %w", o.Path, err))
+			continue
+		}
+		o.Converted = true
+	}
+	return errors.Join(errs...)
+}
+
+// localPaths maps the canonical form of every saved URL, and of the URL it
+// redirected to, to the path of its local copy.
+func (c *WebCrawler) localPaths(state *crawl) map[string]string {
+	local := make(map[string]string, len(state.outcomes))
+	for url, o := range state.outcomes {
+		if o.Path != "" {
+			local[c.canonical(url)] = o.Path
+		}
+	}
+	for _, o := range state.outcomes {
+		finalUrl := c.canonical(o.FinalURL)
+		if _, ok := local[finalUrl]; o.Path != "" && !ok {
+			local[finalUrl] = o.Path
+		}
+	}
+	return local
+}
+
+// convertLinks rewrites the references of a document saved at pagePath so
+// that downloaded URLs point to their local copies and all other URLs become
+// absolute. References are resolved against base.
+func (c *WebCrawler) convertLinks(p parser.Parser, data []byte, base, pagePath string, local map[string]string) ([]byte, error) {
+	refs, err := p.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(data))
+	last := 0
+	for _, ref := range refs {
+		if ref.Start < last || ref.End > len(data) {
+			continue
+		}
+		converted, ok := c.convertUrl(ref.URL, base, pagePath, local)
+		if !ok {
+			continue
+		}
+		// Only attribute values are HTML-escaped; <style> blocks and
+		// stylesheets are raw text.
+		if ref.Attr != "" {
+			converted = html.EscapeString(converted)
+		}
+		out = append(out, data[last:ref.Start]...)
+		out = append(out, converted...)
+		last = ref.End
+	}
+	return append(out, data[last:]...), nil
+}
+
+// convertUrl returns the replacement for value and whether it differs from it.
+func (c *WebCrawler) convertUrl(value, pageUrl, pagePath string, local map[string]string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.HasPrefix(value, "#") {
+		return "", false
+	}
+
+	resolved, err := url.Parse(c.normalizeUrl(pageUrl, value))
+	if err != nil || !resolved.IsAbs() {
+		return "", false
+	}
+	fragment := resolved.EscapedFragment()
+	resolved.Fragment, resolved.RawFragment = "", ""
+
+	converted := resolved.String()
+	if target, ok := local[c.Settings.Canonicalizer.Canonicalize(converted)]; ok {
+		converted = relativePath(pagePath, target)
+	}
+	if fragment != "" {
+		converted += "#" + fragment
+	}
+	return converted, converted != value
+}
+
+// relativePath returns the URL path of to relative to the directory
+// containing from. Both paths are relative to the mirror root.
+func relativePath(from, to string) string {
+	fromDir := strings.Split(path.Dir(from), "/")
+	if fromDir[0] == "." {
+		fromDir = nil
+	}
+	toParts := strings.Split(to, "/")
+
+	common := 0
+	for common < len(fromDir) && common < len(toParts)-1 && fromDir[common] == toParts[common] {
+		common++
+	}
+
+	parts := make([]string, 0, len(fromDir)-common+len(toParts)-common)
+	for range fromDir[common:] {
+		parts = append(parts, "..")
+	}
+	parts = append(parts, toParts[common:]...)
+	return (&url.URL{Path: strings.Join(parts, "/")}).String()
+}
@@ -0,0 +1,165 @@
+package webcrawler
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"wget/downloader"
+	"wget/parser"
+)
+
+// FormSubmitter posts HTML forms, as downloader.HTTPDownloader does.
+type FormSubmitter interface {
+	PostForm(ctx context.Context, url string, values url.Values) (*downloader.Response, error)
+}
+
+// Login is a form login done by Mirror before crawling. The session cookies
+// it receives are kept by the cookie jar that Submitter shares with the
+// Downloader of the crawler.
+type Login struct {
+	URL       string            // page with the login form
+	Fields    map[string]string // values by field name, such as the user name and password
+	Submitter FormSubmitter
+	// Logout matches the URLs and link attributes of links that would end
+	// the session; they are not followed. Nil means DefaultLogoutPattern.
+	Logout *regexp.Regexp
+}
+
+// DefaultLogoutPattern matches "logout", "sign-off" and the like unless a
+// letter precedes them or a lower case letter follows, so that "/logout_btn"
+// and "logoutAll" match but "/blog-offers/" does not.
+var DefaultLogoutPattern = regexp.MustCompile(`(^|[^a-zA-Z])(?i:(log|sign)[-_ ]?(out|off))([^a-z]|$)`)
+
+// logoutAttrs are the link attributes that may name a logout link whose URL
+// does not.
+var logoutAttrs = []string{"id", "class", "title", "aria-label"}
+
+// login fetches the login page, fills in its form and submits it. It fails
+// when the response asks for a password again.
+func (c *WebCrawler) login(ctx context.Context) error {
+	login := c.Settings.Login
+	response, err := c.Downloader.Download(ctx, login.URL)
+	if err != nil {
+		return fmt.Errorf("login page: %w", err)
+	}
+	data, err := response.ReadAll(c.maxParseSize())
+	if err != nil {
+		return fmt.Errorf("login page: %w", err)
+	}
+
+	htmlParser := &parser.HtmlParser{}
+	form := loginForm(htmlParser.ParseForms(data), login.Fields)
+	if form == nil {
+		return fmt.Errorf("no login form on %s", response.URL)
+	}
+	action := c.normalizeUrl(response.URL, form.Action)
+	values := formValues(form, login.Fields)
+
+	if form.Method == "post" {
+		if login.Submitter == nil {
+			return errors.New("login: no FormSubmitter to post the form")
+		}
+		response, err = login.Submitter.PostForm(ctx, action, values)
+	} else {
+		target, parseErr := url.Parse(action)
+		if parseErr != nil {
+			return fmt.Errorf("login: %w", parseErr)
+		}
+		target.RawQuery = values.Encode()
+		response, err = c.Downloader.Download(ctx, target.String())
+	}
+	if err != nil {
+		return fmt.Errorf("login to %s: %w", action, err)
+	}
+	if data, err = response.ReadAll(c.maxParseSize()); err != nil {
+		return fmt.Errorf("login to %s: %w", action, err)
+	}
+	for _, form := range htmlParser.ParseForms(data) {
+		if form.HasPassword() {
+			return fmt.Errorf("login to %s failed: %s asks for a password again", action, response.URL)
+		}
+	}
+	c.logf("Logged in at %s", action)
+	return nil
+}
+
+// loginForm picks the first form with a password field, or else the first
+// form that has all the given fields.
+func loginForm(forms []parser.Form, fields map[string]string) *parser.Form {
+	for i := range forms {
+		if forms[i].HasPassword() {
+			return &forms[i]
+		}
+	}
+	for i := range forms {
+		names := map[string]bool{}
+		for _, field := range forms[i].Fields {
+			names[field.Name] = true
+		}
+		complete := true
+		for name := range fields {
+			complete = complete && names[name]
+		}
+		if complete {
+			return &forms[i]
+		}
+	}
+	return nil
+}
+
+// formValues fills in the form as a browser would, keeping hidden fields
+// such as CSRF tokens, and sets the given fields. The first named submit
+// button is sent as the one clicked.
+func formValues(form *parser.Form, fields map[string]string) url.Values {
+	values := url.Values{}
+	var submit *parser.FormField
+	for i, field := range form.Fields {
+		if field.Name == "" {
+			continue
+		}
+		switch field.Type {
+		case "submit":
+			if submit == nil {
+				submit = &form.Fields[i]
+			}
+		case "image", "button", "reset", "file":
+		case "checkbox", "radio":
+			if field.Checked {
+				values.Add(field.Name, cmp.Or(field.Value, "on"))
+			}
+		default:
+			values.Add(field.Name, field.Value)
+		}
+	}
+	for name, value := range fields {
+		values.Set(name, value)
+	}
+	if submit != nil && !values.Has(submit.Name) {
+		values.Set(submit.Name, submit.Value)
+	}
+	return values
+}
+
+// isLogout reports whether a link looks like it would end the session of
+// the login.
+func (c *WebCrawler) isLogout(rawUrl string, attrs map[string]string) bool {
+	if c.Settings.Login == nil {
+		return false
+	}
+	pattern := c.Settings.Login.Logout
+	if pattern == nil {
+		pattern = DefaultLogoutPattern
+	}
+	if u, err := url.Parse(rawUrl); err == nil && pattern.MatchString(u.RequestURI()) {
+		return true
+	}
+	for _, name := range logoutAttrs {
+		if pattern.MatchString(attrs[name]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package webcrawler
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Politeness limits the load that the crawl puts on every host. Zero values
+// mean no limit.
+type Politeness struct {
+	RequestsPerSecond     float64       // per host
+	MinDelay              time.Duration // between the starts of requests to one host
+	RandomWait            bool          // vary MinDelay from 0.5 to 1.5 times, like wget --random-wait
+	MaxConnectionsPerHost int
+}
+
+// hostScheduler hands out request slots so that every host gets requests no
+// faster than its interval and with no more than MaxConnectionsPerHost at
+// once.
+type hostScheduler struct {
+	politeness Politeness
+
+	mu    sync.Mutex
+	hosts map[string]*hostSlots
+}
+
+type hostSlots struct {
+	next     time.Time // earliest start of the next request
+	active   int
+	released chan struct{} // closed and replaced whenever a request ends
+}
+
+func newHostScheduler(politeness Politeness) *hostScheduler {
+	return &hostScheduler{politeness: politeness, hosts: map[string]*hostSlots{}}
+}
+
+// interval returns the time between the starts of two requests to a host
+// whose robots.txt asks for crawlDelay.
+func (s *hostScheduler) interval(crawlDelay time.Duration) time.Duration {
+	delay := s.politeness.MinDelay
+	if s.politeness.RandomWait && delay > 0 {
+		delay = time.Duration(float64(delay) * (0.5 + rand.Float64()))
+	}
+	if rps := s.politeness.RequestsPerSecond; rps > 0 {
+		delay = max(delay, time.Duration(float64(time.Second)/rps))
+	}
+	return max(delay, crawlDelay)
+}
+
+// acquire waits until a request to host may start. The returned function
+// must be called when the request, including its body, is over.
+func (s *hostScheduler) acquire(ctx context.Context, host string, crawlDelay time.Duration) (func(), error) {
+	host = strings.ToLower(host)
+	for {
+		s.mu.Lock()
+		slots, ok := s.hosts[host]
+		if !ok {
+			slots = &hostSlots{released: make(chan struct{})}
+			s.hosts[host] = slots
+		}
+		if limit := s.politeness.MaxConnectionsPerHost; limit > 0 && slots.active >= limit {
+			released := slots.released
+			s.mu.Unlock()
+			select {
+			case <-released:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		now := time.Now()
+		start := now
+		if slots.next.After(now) {
+			start = slots.next
+		}
+		slots.next = start.Add(s.interval(crawlDelay))
+		slots.active++
+		s.mu.Unlock()
+
+		release := func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			slots.active--
+			close(slots.released)
+			slots.released = make(chan struct{})
+		}
+		if err := wait(ctx, start.Sub(now)); err != nil {
+			release()
+			return nil, err
+		}
+		return release, nil
+	}
+}
+
+// schedule waits for a request slot for rawUrl, honoring the Crawl-delay of
+// robots.txt when robots.txt is respected.
+func (c *WebCrawler) schedule(ctx context.Context, state *crawl, rawUrl string) (func(), error) {
+	host := ""
+	if u, err := url.Parse(rawUrl); err == nil {
+		host = u.Host
+	}
+	var crawlDelay time.Duration
+	if c.Settings.Robots != nil {
+		crawlDelay = c.Settings.Robots.CrawlDelay(ctx, rawUrl)
+	}
+	return state.hosts.acquire(ctx, host, crawlDelay)
+}
+
+func wait(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,108 @@
+package webcrawler
+
+import (
+	"net"
+	"net/url"
+	"path"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+type HostPolicy int
+
+const (
+	// SameHost follows links to the seed host only. Hosts are compared
+	// case-insensitively, ignoring default ports and a leading "www.".
+	SameHost HostPolicy = iota
+	// SameDomain follows links to any host of the seed's registrable domain,
+	// e.g. docs.example.com and blog.example.com.
+	SameDomain
+)
+
+// Scope decides which pages a crawl may follow. A host is in scope unless it
+// matches DenyHosts, and if it matches Policy or AllowHosts, or SpanHosts is
+// set and AllowHosts is empty. Host lists match the host and its subdomains.
+type Scope struct {
+	Policy     HostPolicy
+	SpanHosts  bool
+	AllowHosts []string
+	DenyHosts  []string
+	// NoParent keeps the crawl below the directory of the seed. A seed
+	// without a trailing slash or file extension is treated as a directory.
+	NoParent bool
+}
+
+func (s Scope) Contains(seed, target *url.URL) bool {
+	if target.Scheme != "http" && target.Scheme != "https" {
+		return false
+	}
+	return s.containsHost(seed, target) && (!s.NoParent || containsPath(seed, target))
+}
+
+func (s Scope) containsHost(seed, target *url.URL) bool {
+	host := canonicalHost(target)
+	if matchesAny(host, s.DenyHosts) {
+		return false
+	}
+	if matchesAny(host, s.AllowHosts) || s.SpanHosts && len(s.AllowHosts) == 0 {
+		return true
+	}
+
+	seedHost := canonicalHost(seed)
+	switch s.Policy {
+	case SameDomain:
+		return registrableDomain(host) == registrableDomain(seedHost)
+	default:
+		return strings.TrimPrefix(host, "www.") == strings.TrimPrefix(seedHost, "www.")
+	}
+}
+
+// canonicalHost returns the lowercase host with the default port dropped.
+func canonicalHost(u *url.URL) string {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" || u.Scheme == "http" && port == "80" || u.Scheme == "https" && port == "443" {
+		return host
+	}
+	return net.JoinHostPort(host, port)
+}
+
+func registrableDomain(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
+
+func matchesAny(host string, domains []string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.Trim(domain, ". "))
+		if domain != "" && (host == domain || strings.HasSuffix(host, "."+domain)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsPath(seed, target *url.URL) bool {
+	parent := seed.Path
+	if !strings.HasSuffix(parent, "/") && path.Ext(parent) != "" {
+		parent = path.Dir(parent)
+	}
+	if !strings.HasSuffix(parent, "/") {
+		parent += "/"
+	}
+	targetPath := target.Path
+	if targetPath == "" {
+		targetPath = "/"
+	}
+	return targetPath == strings.TrimSuffix(parent, "/") || strings.HasPrefix(targetPath, parent)
+}
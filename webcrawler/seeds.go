@@ -0,0 +1,54 @@
+package webcrawler
+
+import (
+	"bufio"
+	"io"
+	"net/url"
+	"strings"
+	"wget/parser"
+)
+
+// ReadSeeds reads seed URLs listed one per line. Blank lines and lines
+// starting with "#" are skipped.
+func ReadSeeds(r io.Reader) ([]string, error) {
+	var seeds []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		seeds = append(seeds, line)
+	}
+	return seeds, scanner.Err()
+}
+
+// SeedsFromHTML returns the pages linked from an HTML document, as wget
+// -i --force-html does. Relative links are resolved against base and skipped
+// when that does not make them absolute HTTP(S) URLs.
+func SeedsFromHTML(data []byte, base string) ([]string, error) {
+	baseUrl, err := url.Parse(base)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := (&parser.HtmlParser{}).Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var seeds []string
+	for _, ref := range refs {
+		if ref.Kind != parser.KindPage {
+			continue
+		}
+		link, err := url.Parse(ref.URL)
+		if err != nil {
+			continue
+		}
+		link = baseUrl.ResolveReference(link)
+		if link.Scheme == "http" || link.Scheme == "https" {
+			seeds = append(seeds, link.String())
+		}
+	}
+	return seeds, nil
+}
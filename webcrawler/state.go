@@ -0,0 +1,166 @@
+package webcrawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+	"wget/pathmapper"
+)
+
+// savedState is the checkpoint of a crawl written to Settings.StateFile.
+type savedState struct {
+	Seeds    []string            `json:"seeds"`
+	Result   WebCrawlerResult    `json:"result"`
+	Frontier []savedTask         `json:"frontier"`
+	Visited  []string            `json:"visited"`
+	Outcomes map[string]*outcome `json:"outcomes"`
+	Partials map[string]string   `json:"partials,omitempty"`
+	Files    map[string]string   `json:"files,omitempty"` // assignments of an AssigningPathMapper
+}
+
+type savedTask struct {
+	URL      string `json:"url"`
+	Depth    int    `json:"depth"`
+	Page     bool   `json:"page,omitempty"`
+	Embedded bool   `json:"embedded,omitempty"`
+	Seed     int    `json:"seed,omitempty"`
+}
+
+func (c *WebCrawler) saveState(state *crawl) error {
+	data, err := state.marshal(c.assignedFiles())
+	if err == nil {
+		err = c.FileSaver.Save(c.Settings.StateFile, bytes.NewReader(data))
+	}
+	if err != nil {
+		return fmt.Errorf("save crawl state: %w", err)
+	}
+	return nil
+}
+
+func (s *crawl) marshal(files map[string]string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := savedState{
+		Seeds:    s.seeds,
+		Result:   *s.result,
+		Visited:  make([]string, 0, len(s.processed)),
+		Outcomes: s.outcomes,
+		Partials: s.partials,
+		Files:    files,
+	}
+	for _, t := range s.frontier.snapshot() {
+		saved.Frontier = append(saved.Frontier, savedTask{URL: t.url, Depth: t.depth, Page: t.page, Embedded: t.embedded, Seed: t.seed})
+	}
+	for url := range s.processed {
+		saved.Visited = append(saved.Visited, url)
+	}
+	slices.Sort(saved.Visited)
+	return json.MarshalIndent(saved, "", "  ")
+}
+
+// loadState restores a crawl saved by saveState and reports whether a state
+// file was found.
+func (c *WebCrawler) loadState(state *crawl) (bool, error) {
+	data, err := c.FileSaver.Load(c.Settings.StateFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("load crawl state: %w", err)
+	}
+
+	var saved savedState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return false, fmt.Errorf("load crawl state: %w", err)
+	}
+	if !slices.Equal(saved.Seeds, state.seeds) {
+		return false, fmt.Errorf("load crawl state: %s was saved for %s", c.Settings.StateFile, strings.Join(saved.Seeds, ", "))
+	}
+
+	*state.result = saved.Result
+	for _, url := range saved.Visited {
+		state.processed[url] = true
+	}
+	if saved.Outcomes != nil {
+		state.outcomes = saved.Outcomes
+	}
+	if saved.Partials != nil {
+		state.partials = saved.Partials
+	}
+	c.restoreFiles(saved.Files)
+	for _, t := range saved.Frontier {
+		state.processed[t.URL] = true
+		state.frontier.push(task{url: t.URL, depth: t.Depth, page: t.Page, embedded: t.Embedded, seed: t.Seed})
+	}
+	return true, nil
+}
+
+// loadValidators returns the outcomes of the crawl saved in the state file,
+// whose validators make downloads conditional.
+func (c *WebCrawler) loadValidators() (map[string]*outcome, error) {
+	data, err := c.FileSaver.Load(c.Settings.StateFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load validators: %w", err)
+	}
+
+	var saved savedState
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("load validators: %w", err)
+	}
+	// Documents that are downloaded again keep the files of the outcomes.
+	c.restoreFiles(saved.Files)
+	return saved.Outcomes, nil
+}
+
+// assignedFiles returns the assignments of the PathMapper, if it keeps any.
+func (c *WebCrawler) assignedFiles() map[string]string {
+	if mapper, ok := c.PathMapper.(pathmapper.AssigningPathMapper); ok {
+		return mapper.Assignments()
+	}
+	return nil
+}
+
+func (c *WebCrawler) restoreFiles(files map[string]string) {
+	if mapper, ok := c.PathMapper.(pathmapper.AssigningPathMapper); ok && len(files) > 0 {
+		mapper.Restore(files)
+	}
+}
+
+// startCheckpoints saves the state periodically until the returned function
+// is called.
+func (c *WebCrawler) startCheckpoints(state *crawl) (stop func()) {
+	if c.Settings.StateFile == "" || c.Settings.CheckpointInterval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(c.Settings.CheckpointInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := c.saveState(state); err != nil {
+					c.logf("%v", err)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
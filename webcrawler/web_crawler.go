@@ -1,81 +1,471 @@
 package webcrawler
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
 	"net/url"
-	"strings"
+	"sync"
+	"time"
 	"wget/downloader"
 	"wget/parser"
 	"wget/pathmapper"
+	"wget/sitemap"
 	"wget/storage"
 )
 
 type WebCrawler struct {
 	Downloader downloader.Downloader
-	Parser     parser.Parser
+	Parsers    *parser.Registry
 	PathMapper pathmapper.PathMapper
 	FileSaver  storage.FileSaver
 	Settings   WebCrawlerSettings
 }
 
+// RobotsChecker tells whether robots.txt allows crawling a URL and how long
+// to wait between requests to its host.
+type RobotsChecker interface {
+	Allowed(ctx context.Context, rawUrl string) bool
+	CrawlDelay(ctx context.Context, rawUrl string) time.Duration
+}
+
+// SitemapSource lists the pages a site advertises in its sitemaps.
+type SitemapSource interface {
+	Pages(ctx context.Context, seed string) ([]sitemap.Page, error)
+}
+
 type WebCrawlerSettings struct {
-	MaxDepth   int
-	MaxWorkers int
+	MaxDepth      int
+	MaxWorkers    int
+	Scope         Scope
+	Canonicalizer Canonicalizer
+	Politeness    Politeness
+	Robots        RobotsChecker // optional, nil ignores robots.txt
+	Sitemaps      SitemapSource // optional, seeds the crawl with the listed pages
+	Login         *Login        // optional, logs in before crawling and avoids logout links
+	Filter        Filter        // applied to links in scope and to response types
+	// PageRequisites also fetches what in-scope pages need to render from
+	// hosts out of Scope: from RequisiteHosts, or from any host Scope does
+	// not deny when it is empty. Otherwise requisites come from the hosts in
+	// Scope only.
+	PageRequisites bool
+	RequisiteHosts []string
+	ModifiedSince  time.Time // sitemap pages not modified after it are skipped
+	MaxParseSize   int64     // documents to parse are buffered up to it, 0 means defaultMaxParseSize
+	ConvertLinks   bool
+	// Timestamping re-fetches documents only when they changed, according to
+	// the ETag and Last-Modified recorded in StateFile by the previous crawl.
+	Timestamping bool
+	Logger       *log.Logger // optional, reports skipped and unparsable documents
+
+	// StateFile is the path, relative to the output, where the crawl state
+	// is checkpointed every CheckpointInterval and when Mirror returns.
+	// Continue resumes the crawl recorded there.
+	StateFile          string
+	CheckpointInterval time.Duration
+	Continue           bool
 }
 
+// defaultMaxParseSize is the largest HTML or CSS document whose links are
+// followed.
+const defaultMaxParseSize = 16 * 1024 * 1024
+
 type WebCrawlerResult struct {
 	CountSuccess int
 	CountError   int
 }
 
+// crawl holds the state of a single Mirror call shared between workers.
+type crawl struct {
+	seeds    []string
+	seedUrls []*url.URL
+	frontier *frontier
+	hosts    *hostScheduler
+
+	// previous holds the outcomes of the last crawl when Timestamping is on.
+	// It is never modified.
+	previous map[string]*outcome
+
+	mu        sync.Mutex
+	processed map[string]bool
+	outcomes  map[string]*outcome
+	partials  map[string]string // URL to the If-Range validator of its partial file
+	result    *WebCrawlerResult
+}
+
+// outcome is the result of processing a single URL.
+type outcome struct {
+	Depth       int    `json:"depth"`
+	Path        string `json:"path,omitempty"` // set when the response was saved
+	FinalURL    string `json:"final_url,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Converted   bool   `json:"converted,omitempty"` // links rewritten by ConvertLinks
+
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
 func NewWebCrawler(
 	downloader downloader.Downloader,
-	parser parser.Parser,
+	parsers *parser.Registry,
 	pathMapper pathmapper.PathMapper,
 	fileSaver storage.FileSaver,
 	settings WebCrawlerSettings,
 ) *WebCrawler {
 	return &WebCrawler{
 		Downloader: downloader,
-		Parser:     parser,
+		Parsers:    parsers,
 		PathMapper: pathMapper,
 		FileSaver:  fileSaver,
 		Settings:   settings,
 	}
 }
 
-func (c *WebCrawler) Mirror(ctx context.Context, url string) (*WebCrawlerResult, error) {
-	result := &WebCrawlerResult{}
-	return result, c.mirror(ctx, url, url, 1, map[string]bool{}, result)
+// Mirror crawls from every seed, each within its own scope. The seeds share
+// one set of visited URLs and one result.
+func (c *WebCrawler) Mirror(ctx context.Context, seeds ...string) (*WebCrawlerResult, error) {
+	if len(seeds) == 0 {
+		return &WebCrawlerResult{}, errors.New("no seed URL to mirror")
+	}
+	seedUrls := make([]*url.URL, len(seeds))
+	for i, rawUrl := range seeds {
+		seedUrl, err := url.Parse(rawUrl)
+		if err != nil {
+			return &WebCrawlerResult{}, err
+		}
+		seedUrls[i] = seedUrl
+	}
+	if c.Settings.Login != nil {
+		if err := c.login(ctx); err != nil {
+			return &WebCrawlerResult{}, err
+		}
+	}
+
+	var err error
+	state := &crawl{
+		seeds:     seeds,
+		seedUrls:  seedUrls,
+		frontier:  newFrontier(),
+		hosts:     newHostScheduler(c.Settings.Politeness),
+		processed: map[string]bool{},
+		outcomes:  map[string]*outcome{},
+		partials:  map[string]string{},
+		result:    &WebCrawlerResult{},
+	}
+	stop := context.AfterFunc(ctx, state.frontier.close)
+	defer stop()
+
+	if c.Settings.Timestamping && c.Settings.StateFile != "" {
+		if state.previous, err = c.loadValidators(); err != nil {
+			return state.result, err
+		}
+	}
+
+	resumed := false
+	if c.Settings.Continue && c.Settings.StateFile != "" {
+		if resumed, err = c.loadState(state); err != nil {
+			return state.result, err
+		}
+	}
+	if !resumed {
+		for i, rawUrl := range seeds {
+			// The seed is fetched as given but deduplicated by its canonical form.
+			state.enqueue(task{url: rawUrl, depth: 1, page: true, seed: i})
+			state.visit(c.canonical(rawUrl))
+		}
+		for i := range seeds {
+			c.seedFromSitemaps(ctx, state, i)
+		}
+	}
+
+	stopCheckpoints := c.startCheckpoints(state)
+	var wg sync.WaitGroup
+	for i := 0; i < c.workers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.work(ctx, state)
+		}()
+	}
+	wg.Wait()
+	stopCheckpoints()
+
+	var errs []error
+	if c.Settings.ConvertLinks && ctx.Err() == nil {
+		errs = append(errs, c.convertPages(state))
+	}
+	// The state is saved after the conversion, which it records.
+	if c.Settings.StateFile != "" {
+		errs = append(errs, c.saveState(state))
+	}
+	errs = append(errs, ctx.Err())
+	return state.result, errors.Join(errs...)
+}
+
+// seedFromSitemaps enqueues the in-scope pages listed in the sitemaps of the
+// seed as additional seeds.
+func (c *WebCrawler) seedFromSitemaps(ctx context.Context, state *crawl, seed int) {
+	if c.Settings.Sitemaps == nil {
+		return
+	}
+	pages, err := c.Settings.Sitemaps.Pages(ctx, state.seeds[seed])
+	if err != nil {
+		c.logf("%v", err)
+	}
+	for _, page := range pages {
+		pageUrl := c.canonical(page.URL)
+		if !c.inScope(state, seed, pageUrl) || !c.passesFilter(state, pageUrl, true) ||
+			!c.allowedByRobots(ctx, state, pageUrl) || !c.keepsSession(state, pageUrl, nil) {
+			continue
+		}
+		if c.unchanged(page) {
+			// Links must not fetch it either.
+			c.skip(state, pageUrl, "%s is not modified since %s", pageUrl, c.Settings.ModifiedSince.Format(time.RFC3339))
+			continue
+		}
+		state.enqueue(task{url: pageUrl, depth: 1, page: true, seed: seed})
+	}
 }
 
-func (c *WebCrawler) mirror(ctx context.Context, baseUrl, url string, depth int, processed map[string]bool, result *WebCrawlerResult) error {
-	data, err := c.download(ctx, url)
-	c.check(result, err)
-	processed[url] = true
+func (c *WebCrawler) unchanged(page sitemap.Page) bool {
+	since := c.Settings.ModifiedSince
+	return !since.IsZero() && !page.LastMod.IsZero() && !page.LastMod.After(since)
+}
 
-	resources, links, err := c.Parser.ParseHTML(data)
+func (c *WebCrawler) workers() int {
+	if c.Settings.MaxWorkers < 1 {
+		return 1
+	}
+	return c.Settings.MaxWorkers
+}
 
-	for _, resource := range resources {
-		currentUrl := c.normalizeUrl(url, resource)
-		if !processed[currentUrl] {
-			processed[currentUrl] = true
-			_, err := c.download(ctx, currentUrl)
-			c.check(result, err)
+func (c *WebCrawler) work(ctx context.Context, state *crawl) {
+	for {
+		t, ok := state.frontier.pop()
+		if !ok || ctx.Err() != nil {
+			return
 		}
+		c.process(ctx, state, t)
+		if ctx.Err() != nil {
+			// Leave t unfinished so that it is saved for Continue.
+			return
+		}
+		state.frontier.done(t)
+	}
+}
+
+func (c *WebCrawler) process(ctx context.Context, state *crawl, t task) {
+	response, data, path, err := c.download(ctx, state, t.url)
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+	var rejected *rejection
+	if errors.As(err, &rejected) {
+		c.logf("Not saving %s: %s", t.url, rejected.rule)
+	} else {
+		state.record(t, response, path, err)
+	}
+	if response == nil {
+		return
+	}
+	state.visit(c.canonical(response.URL))
+	if data == nil || !followsReferences(t, response.ContentType) {
+		return
 	}
 
-	for _, link := range links {
-		currentUrl := c.normalizeUrl(url, link)
-		if depth < c.Settings.MaxDepth && !processed[currentUrl] && strings.HasPrefix(currentUrl, baseUrl) {
-			err = c.mirror(ctx, baseUrl, currentUrl, depth+1, processed, result)
-			if err != nil {
-				return err
+	p, _ := c.Parsers.Lookup(response.ContentType)
+	refs, err := p.Parse(data)
+	if err != nil {
+		c.logf("parse %s: %v", t.url, err)
+		return
+	}
+
+	for _, ref := range refs {
+		currentUrl := c.canonical(c.normalizeUrl(response.URL, ref.URL))
+		if ref.Kind != parser.KindPage {
+			if c.requisiteInScope(state, t.seed, currentUrl) && c.passesFilter(state, currentUrl, false) &&
+				c.allowedByRobots(ctx, state, currentUrl) {
+				state.enqueue(task{url: currentUrl, depth: t.depth, embedded: t.page && ref.Kind == parser.KindEmbedded, seed: t.seed})
 			}
+			continue
 		}
+		if t.page && t.depth < c.Settings.MaxDepth && c.inScope(state, t.seed, currentUrl) &&
+			c.passesFilter(state, currentUrl, true) && c.allowedByRobots(ctx, state, currentUrl) &&
+			c.keepsSession(state, currentUrl, ref.Attrs) {
+			state.enqueue(task{url: currentUrl, depth: t.depth + 1, page: true, seed: t.seed})
+		}
+	}
+}
+
+// followsReferences tells whether the references of a downloaded document
+// are followed. Those of a requisite are only when it is a stylesheet, for
+// its imports and images, or a document embedded into a page, whose own
+// requisites are not parsed further. This keeps chains of requisites from
+// getting past MaxDepth and Scope.
+func followsReferences(t task, contentType string) bool {
+	if t.page || t.embedded {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "text/css"
+}
+
+func (c *WebCrawler) canonical(rawUrl string) string {
+	return c.Settings.Canonicalizer.Canonicalize(rawUrl)
+}
+
+func (c *WebCrawler) inScope(state *crawl, seed int, rawUrl string) bool {
+	target, err := url.Parse(rawUrl)
+	return err == nil && c.Settings.Scope.Contains(state.seedUrls[seed], target)
+}
+
+// requisiteInScope applies the hosts of Scope, widened by PageRequisites,
+// to a requisite of a page crawled from the seed. Requisites are fetched
+// even from above the directory of the seed.
+func (c *WebCrawler) requisiteInScope(state *crawl, seed int, rawUrl string) bool {
+	target, err := url.Parse(rawUrl)
+	if err != nil || target.Scheme != "http" && target.Scheme != "https" {
+		return false
+	}
+	scope := c.Settings.Scope
+	if scope.containsHost(state.seedUrls[seed], target) {
+		return true
+	}
+	if !c.Settings.PageRequisites {
+		return false
 	}
+	host := canonicalHost(target)
+	return !matchesAny(host, scope.DenyHosts) &&
+		(len(c.Settings.RequisiteHosts) == 0 || matchesAny(host, c.Settings.RequisiteHosts))
+}
 
-	return nil
+// allowedByRobots consults robots.txt for URLs that were not seen yet.
+func (c *WebCrawler) allowedByRobots(ctx context.Context, state *crawl, rawUrl string) bool {
+	if c.Settings.Robots == nil || state.seen(rawUrl) {
+		return true
+	}
+	if !c.Settings.Robots.Allowed(ctx, rawUrl) {
+		c.skip(state, rawUrl, "robots.txt disallows %s", rawUrl)
+		return false
+	}
+	return true
+}
+
+// passesFilter applies Filter to a link before it is enqueued. A page
+// rejected by name is still enqueued when it may be HTML, so that the
+// documents it links to are reached.
+func (c *WebCrawler) passesFilter(state *crawl, rawUrl string, page bool) bool {
+	rule := c.Settings.Filter.CheckURL(rawUrl)
+	if rule == "" && !(page && mayBeHTML(rawUrl)) {
+		rule = c.Settings.Filter.CheckName(rawUrl)
+	}
+	if rule == "" {
+		return true
+	}
+	c.skip(state, rawUrl, "Rejected %s: %s", rawUrl, rule)
+	return false
+}
+
+// rejection is returned by download for a document that Filter rejects
+// once its headers arrive.
+type rejection struct {
+	rule string
+}
+
+func (r *rejection) Error() string {
+	return "rejected by " + r.rule
+}
+
+// keepsSession skips links that look like logging out of the Login session.
+func (c *WebCrawler) keepsSession(state *crawl, rawUrl string, attrs map[string]string) bool {
+	if !c.isLogout(rawUrl, attrs) {
+		return true
+	}
+	c.skip(state, rawUrl, "Not following logout link %s", rawUrl)
+	return false
+}
+
+// skip marks a URL that is not to be fetched as processed, so that it is
+// neither reconsidered nor logged again, and logs the reason the first time.
+func (c *WebCrawler) skip(state *crawl, rawUrl, format string, args ...any) {
+	if state.visit(rawUrl) {
+		c.logf(format, args...)
+	}
+}
+
+func (s *crawl) seen(url string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.processed[url]
+}
+
+// visit marks url as processed and reports whether it was seen for the first time.
+func (s *crawl) visit(url string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.visitLocked(url)
+}
+
+func (s *crawl) visitLocked(url string) bool {
+	if s.processed[url] {
+		return false
+	}
+	s.processed[url] = true
+	return true
+}
+
+// enqueue schedules t unless its URL was already processed. Marking and
+// pushing happen under one lock so that saved state never has a visited URL
+// that is neither queued nor finished.
+func (s *crawl) enqueue(t task) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.visitLocked(t.url) {
+		return false
+	}
+	s.frontier.push(t)
+	return true
+}
+
+func (s *crawl) record(t task, response *downloader.Response, path string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// A task interrupted after it finished is processed again on Continue.
+	if previous, ok := s.outcomes[t.url]; ok {
+		if previous.Error != "" {
+			s.result.CountError--
+		} else {
+			s.result.CountSuccess--
+		}
+	}
+
+	o := &outcome{Depth: t.depth}
+	if response != nil {
+		o.FinalURL = response.URL
+		o.ContentType = response.ContentType
+		o.ETag = response.Header.Get("ETag")
+		o.LastModified = response.Header.Get("Last-Modified")
+		if previous := s.previous[t.url]; response.NotModified && previous != nil {
+			o.ETag = cmp.Or(o.ETag, previous.ETag)
+			o.LastModified = cmp.Or(o.LastModified, previous.LastModified)
+		}
+	}
+	if err != nil {
+		o.Error = err.Error()
+		s.result.CountError++
+	} else {
+		o.Path = path
+		s.result.CountSuccess++
+	}
+	s.outcomes[t.url] = o
 }
 
 func (c *WebCrawler) normalizeUrl(baseUrl, currentUrl string) string {
@@ -92,29 +482,228 @@ func (c *WebCrawler) normalizeUrl(baseUrl, currentUrl string) string {
 	return result.String()
 }
 
-func (c *WebCrawler) download(ctx context.Context, url string) ([]byte, error) {
-	data, err := c.Downloader.Download(ctx, url)
+// download waits for a request slot of the host and streams the response to
+// storage, holding the slot until the body is saved. The body of a document
+// that can be parsed is also kept in memory, unless it exceeds MaxParseSize;
+// data is nil otherwise. A document that Filter rejects by its type or name
+// is not saved and a *rejection is returned, with data if it can be parsed.
+func (c *WebCrawler) download(ctx context.Context, state *crawl, url string) (response *downloader.Response, data []byte, path string, err error) {
+	release, err := c.schedule(ctx, state, url)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	defer release()
+
+	previous := state.previous[url]
+	path = c.localPath(state, url)
+	requestCtx, conditional := c.conditional(ctx, previous)
+	offset, validator := c.partial(state, url, path)
+	if offset > 0 {
+		requestCtx = downloader.WithRange(ctx, downloader.Range{Start: offset, IfRange: validator})
+		conditional = false
+	}
+
+	response, err = c.Downloader.Download(requestCtx, url)
+	var downloadErr *downloader.DownloadError
+	if offset > 0 && errors.As(err, &downloadErr) && downloadErr.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		// The partial file does not fit the document any more.
+		response, err = c.Downloader.Download(ctx, url)
+	}
+	if err == nil && response.NotModified && conditional {
+		response.Body.Close()
+		data, err = c.reuse(response, previous)
+		if !errors.Is(err, fs.ErrNotExist) {
+			return response, data, previous.Path, err
+		}
+		// The local copy is gone, so download it again.
+		response, err = c.Downloader.Download(ctx, url)
+	}
+	if err == nil && response.NotModified {
+		// Only the conditions of a stored outcome make a 304 confirm a local
+		// copy. Any other 304, e.g. for a user's If-None-Match header, leaves
+		// nothing to save.
+		response.Body.Close()
+		err = &downloader.DownloadError{URL: url, StatusCode: http.StatusNotModified}
+	}
 	if err != nil {
+		return nil, nil, "", err
+	}
+	defer response.Body.Close()
+
+	_, parsable := c.Parsers.Lookup(response.ContentType)
+	rule := c.Settings.Filter.CheckType(response.ContentType)
+	if rule == "" {
+		rule = c.Settings.Filter.CheckName(url)
+	}
+	if rule != "" {
+		// A rejected document that can be parsed is still read for its
+		// links, but not saved.
+		if !parsable {
+			return response, nil, "", &rejection{rule: rule}
+		}
+		buffer := &limitedBuffer{limit: c.maxParseSize()}
+		if _, err = io.Copy(buffer, response.Body); err != nil {
+			return response, nil, "", err
+		}
+		if buffer.truncated {
+			c.logf("%s is larger than %d bytes, its links are not followed", url, buffer.limit)
+			return response, nil, "", &rejection{rule: rule}
+		}
+		return response, buffer.Bytes(), "", &rejection{rule: rule}
+	}
+
+	var body io.Reader = response.Body
+	var buffer *limitedBuffer
+	if parsable && response.Offset == 0 {
+		buffer = &limitedBuffer{limit: c.maxParseSize()}
+		body = io.TeeReader(body, buffer)
+	}
+
+	if response.Offset > 0 {
+		err = c.FileSaver.Resume(path, response.Offset, body)
+	} else {
+		err = c.FileSaver.Save(path, body)
+	}
+	if err != nil {
+		// What was received is kept in the partial file for a later Continue.
+		state.setPartial(url, downloader.RangeValidator(response.Header))
+		return response, nil, path, err
+	}
+	state.setPartial(url, "")
+
+	if err = c.setModTime(path, response.Header.Get("Last-Modified")); err != nil || !parsable {
+		return response, nil, path, err
+	}
+	if buffer == nil {
+		// A resumed document is read back in full.
+		data, err = c.loadForParsing(path)
+		return response, data, path, err
+	}
+	if buffer.truncated {
+		c.logf("%s is larger than %d bytes, its links are not followed", url, buffer.limit)
+		return response, nil, path, nil
+	}
+	return response, buffer.Bytes(), path, nil
+}
+
+// partial returns the size of the partial file left for url by an earlier
+// attempt and the validator of the version it holds.
+func (c *WebCrawler) partial(state *crawl, url, path string) (int64, string) {
+	state.mu.Lock()
+	validator := state.partials[url]
+	state.mu.Unlock()
+	if validator == "" {
+		return 0, ""
+	}
+	return c.FileSaver.Partial(path), validator
+}
+
+func (s *crawl) setPartial(url, validator string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if validator == "" {
+		delete(s.partials, url)
+	} else {
+		s.partials[url] = validator
+	}
+}
+
+// localPath maps a URL to its file. URLs from hosts outside the scope of
+// every seed, such as requisites from a CDN, keep their host directory when
+// the mapper can keep it, so that they do not overwrite files of the site.
+func (c *WebCrawler) localPath(state *crawl, rawUrl string) string {
+	mapper, ok := c.PathMapper.(pathmapper.HostPathMapper)
+	target, err := url.Parse(rawUrl)
+	if !ok || err != nil {
+		return c.PathMapper.Map(rawUrl)
+	}
+	for _, seedUrl := range state.seedUrls {
+		if c.Settings.Scope.containsHost(seedUrl, target) {
+			return mapper.Map(rawUrl)
+		}
+	}
+	return mapper.MapWithHost(rawUrl)
+}
+
+// conditional makes the download conditional on the validators of a saved
+// copy recorded by the previous crawl and reports whether it did. Documents
+// whose links were converted no longer hold the original links, so they are
+// always downloaded again.
+func (c *WebCrawler) conditional(ctx context.Context, previous *outcome) (context.Context, bool) {
+	if previous == nil || previous.Path == "" {
+		return ctx, false
+	}
+	if _, ok := c.Parsers.Lookup(previous.ContentType); ok && c.Settings.ConvertLinks {
+		return ctx, false
+	}
+	return downloader.WithConditions(ctx, downloader.Conditions{ETag: previous.ETag, LastModified: previous.LastModified}), true
+}
+
+// reuse completes a response confirming the local copy recorded in previous
+// and returns the copy if it has to be parsed.
+func (c *WebCrawler) reuse(response *downloader.Response, previous *outcome) ([]byte, error) {
+	response.ContentType = cmp.Or(previous.ContentType, response.ContentType)
+	lastModified := cmp.Or(response.Header.Get("Last-Modified"), previous.LastModified)
+	if err := c.setModTime(previous.Path, lastModified); err != nil {
 		return nil, err
 	}
+	if _, ok := c.Parsers.Lookup(response.ContentType); !ok {
+		return nil, nil
+	}
+	return c.loadForParsing(previous.Path)
+}
 
-	err = c.saveData(url, data)
+// loadForParsing reads a saved document back unless it exceeds MaxParseSize.
+func (c *WebCrawler) loadForParsing(path string) ([]byte, error) {
+	data, err := c.FileSaver.Load(path)
 	if err != nil {
-		return data, err
+		return nil, err
+	}
+	if int64(len(data)) > c.maxParseSize() {
+		return nil, nil
 	}
 	return data, nil
 }
 
-func (c *WebCrawler) check(result *WebCrawlerResult, err error) {
+// setModTime sets the modification time of a saved file to the Last-Modified
+// date of its response, as wget does.
+func (c *WebCrawler) setModTime(path, lastModified string) error {
+	modTime, err := http.ParseTime(lastModified)
 	if err != nil {
-		result.CountError++
-	} else {
-		result.CountSuccess++
+		return nil
 	}
+	return c.FileSaver.SetModTime(path, modTime)
 }
 
-func (c *WebCrawler) saveData(url string, data []byte) error {
-	path := c.PathMapper.Map(url)
-	err := c.FileSaver.Save(path, data)
-	return err
+func (c *WebCrawler) maxParseSize() int64 {
+	if c.Settings.MaxParseSize < 1 {
+		return defaultMaxParseSize
+	}
+	return c.Settings.MaxParseSize
+}
+
+// limitedBuffer keeps what is written to it up to limit bytes. Past that it
+// drops everything and only records that it was truncated.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int64
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.truncated {
+		return len(p), nil
+	}
+	if int64(b.Len()+len(p)) > b.limit {
+		b.truncated = true
+		b.Reset()
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+func (c *WebCrawler) logf(format string, args ...any) {
+	if c.Settings.Logger != nil {
+		c.Settings.Logger.Printf(format, args...)
+	}
 }